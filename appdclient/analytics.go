@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package appdclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// AnalyticsContentType is the content type expected by the AppDynamics Analytics Events API.
+const AnalyticsContentType = "application/vnd.appd.events+json;v=2"
+
+// AnalyticsSchemaExists reports whether the schema exists in the Analytics Events API. The client has to be created for
+// the Events Service, with the account name and API key headers set.
+func (c *Client) AnalyticsSchemaExists(ctx context.Context, name string) (bool, error) {
+	res, err := c.executeAnalytics(ctx, resty.MethodGet, "/events/schema/"+url.PathEscape(name), nil)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case res.IsSuccess():
+		return true, nil
+	case res.StatusCode() == http.StatusNotFound:
+		return false, nil
+	default:
+		return false, &StatusError{StatusCode: res.StatusCode(), Body: res.String()}
+	}
+}
+
+func (c *Client) CreateAnalyticsSchema(ctx context.Context, name string, schema map[string]string) error {
+	return c.doAnalytics(ctx, resty.MethodPost, "/events/schema/"+url.PathEscape(name), map[string]any{"schema": schema})
+}
+
+func (c *Client) PublishAnalyticsEvents(ctx context.Context, schemaName string, records []map[string]any) error {
+	return c.doAnalytics(ctx, resty.MethodPost, "/events/publish/"+url.PathEscape(schemaName), records)
+}
+
+func (c *Client) doAnalytics(ctx context.Context, method string, uri string, body any) error {
+	res, err := c.executeAnalytics(ctx, method, uri, body)
+	if err != nil {
+		return err
+	}
+	if !res.IsSuccess() {
+		return &StatusError{StatusCode: res.StatusCode(), Body: res.String()}
+	}
+	return nil
+}
+
+func (c *Client) executeAnalytics(ctx context.Context, method string, uri string, body any) (*resty.Response, error) {
+	res, err := c.execute(ctx, method, uri, func(req *resty.Request) {
+		req.SetHeader("Content-Type", AnalyticsContentType)
+		req.SetHeader("Accept", AnalyticsContentType)
+		if body != nil {
+			req.SetBody(body)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s %s: %w", method, uri, err)
+	}
+	return res, nil
+}
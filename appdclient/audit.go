@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package appdclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// AuditLogStdout is the audit log destination writing to stdout instead of a file.
+const AuditLogStdout = "stdout"
+
+// AuditInitiator identifies who triggered a request, e.g. the experiment execution an action runs in.
+type AuditInitiator struct {
+	Team          string `json:"team,omitempty"`
+	ExperimentKey string `json:"experimentKey,omitempty"`
+	ExecutionId   int    `json:"executionId,omitempty"`
+	StepId        string `json:"stepId,omitempty"`
+}
+
+type auditInitiatorKey struct{}
+
+// WithAuditInitiator returns a context whose mutating requests are audited as triggered by the initiator.
+func WithAuditInitiator(ctx context.Context, initiator AuditInitiator) context.Context {
+	return context.WithValue(ctx, auditInitiatorKey{}, initiator)
+}
+
+// AuditRecord describes a single mutating request to a controller.
+type AuditRecord struct {
+	Time       time.Time      `json:"time"`
+	Initiator  AuditInitiator `json:"initiator"`
+	Controller string         `json:"controller"`
+	Method     string         `json:"method"`
+	// Entity is the path of the entity the request created, changed or deleted.
+	Entity string `json:"entity"`
+	// BodySha256 is the digest of the request body and query, so that the request can be verified without logging
+	// its content.
+	BodySha256 string `json:"bodySha256,omitempty"`
+	Outcome    string `json:"outcome"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// AuditLog appends an AuditRecord as JSON line for every mutating request to a file or stdout. It may be shared by the
+// clients of all controllers.
+type AuditLog struct {
+	destination string
+	mutex       sync.Mutex
+}
+
+func NewAuditLog(destination string) *AuditLog {
+	return &AuditLog{destination: destination}
+}
+
+// WithAuditLog audits all mutating requests of the client.
+func WithAuditLog(auditLog *AuditLog) Option {
+	return func(c *Client) {
+		c.auditLog = auditLog
+	}
+}
+
+func (l *AuditLog) Record(record AuditRecord) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encode audit record.")
+		return
+	}
+	line = append(line, '\n')
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.destination == AuditLogStdout {
+		_, err = os.Stdout.Write(line)
+	} else {
+		err = appendToFile(l.destination, line)
+	}
+	if err != nil {
+		log.Error().Err(err).RawJSON("record", line[:len(line)-1]).Msg("Failed to write audit record.")
+	}
+}
+
+func appendToFile(path string, line []byte) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(line); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func isMutating(method string) bool {
+	return method == resty.MethodPost || method == resty.MethodPut || method == resty.MethodPatch || method == resty.MethodDelete
+}
+
+func getBodyDigest(req *resty.Request) string {
+	hash := sha256.New()
+	switch body := req.Body.(type) {
+	case nil:
+	case string:
+		hash.Write([]byte(body))
+	case []byte:
+		hash.Write(body)
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return ""
+		}
+		hash.Write(encoded)
+	}
+	if len(req.QueryParam) > 0 {
+		hash.Write([]byte(req.QueryParam.Encode()))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (c *Client) audit(ctx context.Context, method string, uri string, bodyDigest string, res *resty.Response, err error) {
+	initiator, _ := ctx.Value(auditInitiatorKey{}).(AuditInitiator)
+	path, _, _ := strings.Cut(uri, "?")
+	record := AuditRecord{
+		Time:       time.Now().UTC(),
+		Initiator:  initiator,
+		Controller: c.name,
+		Method:     method,
+		Entity:     path,
+		BodySha256: bodyDigest,
+		Outcome:    "failure",
+	}
+	if err != nil {
+		record.Error = err.Error()
+	} else if res != nil {
+		record.StatusCode = res.StatusCode()
+		if res.IsSuccess() {
+			record.Outcome = "success"
+		}
+	}
+	c.auditLog.Record(record)
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package appdclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func readAuditRecords(t *testing.T, path string) []AuditRecord {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	var records []AuditRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record AuditRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestAuditLogRecordsMutatingRequests(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPost:
+			_, _ = w.Write([]byte(`{"id":99}`))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			_, _ = w.Write([]byte(`[]`))
+		}
+	})
+	path := filepath.Join(t.TempDir(), "audit.log")
+	WithAuditLog(NewAuditLog(path))(client)
+	WithName("onprem")(client)
+
+	ctx := WithAuditInitiator(context.Background(), AuditInitiator{Team: "ADM", ExperimentKey: "ADM-1", ExecutionId: 12, StepId: "step-1"})
+	_, err := client.ListApplications(ctx)
+	require.NoError(t, err)
+	_, err = client.CreateActionSuppression(ctx, "42", ActionSuppressionRequest{Name: "steadybit"})
+	require.NoError(t, err)
+	require.Error(t, client.DeleteActionSuppression(ctx, "42", "99"))
+
+	records := readAuditRecords(t, path)
+	require.Len(t, records, 2)
+
+	created := records[0]
+	require.Equal(t, AuditInitiator{Team: "ADM", ExperimentKey: "ADM-1", ExecutionId: 12, StepId: "step-1"}, created.Initiator)
+	require.Equal(t, "onprem", created.Controller)
+	require.Equal(t, http.MethodPost, created.Method)
+	require.Equal(t, "/controller/alerting/rest/v1/applications/42/action-suppressions", created.Entity)
+	require.Len(t, created.BodySha256, 64)
+	require.Equal(t, "success", created.Outcome)
+	require.Equal(t, http.StatusOK, created.StatusCode)
+
+	deleted := records[1]
+	require.Equal(t, http.MethodDelete, deleted.Method)
+	require.Equal(t, "/controller/alerting/rest/v1/applications/42/action-suppressions/99", deleted.Entity)
+	require.Equal(t, "failure", deleted.Outcome)
+	require.Equal(t, http.StatusForbidden, deleted.StatusCode)
+}
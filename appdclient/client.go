@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+// Package appdclient is a typed client for the REST API of an AppDynamics controller.
+package appdclient
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"golang.org/x/time/rate"
+)
+
+// StatusError is returned if the controller responds with a non-successful status code.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("AppDynamics API responded with unexpected status code %d. Full response: %s", e.StatusCode, e.Body)
+}
+
+// Client calls the REST API of a single AppDynamics controller. Authentication is up to the given resty client.
+type Client struct {
+	name    string
+	client  *resty.Client
+	retry   RetryPolicy
+	limiter *rate.Limiter
+	// auditLog records all mutating requests if set.
+	auditLog *AuditLog
+}
+
+func New(client *resty.Client, options ...Option) *Client {
+	c := &Client{client: client}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// BaseUrl returns the url of the controller, e.g. to build deep links into the controller UI.
+func (c *Client) BaseUrl() string {
+	return c.client.BaseURL
+}
+
+func (c *Client) ListApplications(ctx context.Context) ([]Application, error) {
+	var applications []Application
+	err := c.do(ctx, resty.MethodGet, "/controller/rest/applications?output=JSON", nil, &applications)
+	return applications, err
+}
+
+func (c *Client) ListHealthRules(ctx context.Context, applicationId string) ([]HealthRule, error) {
+	var healthRules []HealthRule
+	err := c.do(ctx, resty.MethodGet, "/controller/alerting/rest/v1/applications/"+url.PathEscape(applicationId)+"/health-rules?output=JSON", nil, &healthRules)
+	return healthRules, err
+}
+
+// GetViolations returns the health rule violations of an application within the given time range.
+func (c *Client) GetViolations(ctx context.Context, applicationId string, start time.Time, end time.Time) ([]Violation, error) {
+	var violations []Violation
+	uri := "/controller/rest/applications/" + url.PathEscape(applicationId) + "/problems/healthrule-violations?output=JSON&time-range-type=BETWEEN_TIMES&start-time=" + strconv.FormatInt(start.UnixMilli(), 10) + "&end-time=" + strconv.FormatInt(end.UnixMilli(), 10)
+	err := c.do(ctx, resty.MethodGet, uri, nil, &violations)
+	return violations, err
+}
+
+// ListBackends returns the backends the tiers of the application call.
+func (c *Client) ListBackends(ctx context.Context, applicationId string) ([]Backend, error) {
+	var backends []Backend
+	err := c.do(ctx, resty.MethodGet, "/controller/rest/applications/"+url.PathEscape(applicationId)+"/backends?output=JSON", nil, &backends)
+	return backends, err
+}
+
+// ListDatabaseCollectors returns the collectors of the databases monitored by Database Visibility.
+func (c *Client) ListDatabaseCollectors(ctx context.Context) ([]DatabaseCollector, error) {
+	var collectors []DatabaseCollector
+	err := c.do(ctx, resty.MethodGet, "/controller/rest/databases/collectors", nil, &collectors)
+	return collectors, err
+}
+
+// ListMachines returns the machines monitored by Server Visibility (SIM).
+func (c *Client) ListMachines(ctx context.Context) ([]Machine, error) {
+	var machines []Machine
+	err := c.do(ctx, resty.MethodGet, "/controller/sim/v2/user/machines", nil, &machines)
+	return machines, err
+}
+
+// GetMetricData returns the values of the metrics matching the metric path within the given time range. The path may
+// contain wildcards, e.g. "Backends|*|Errors per Minute".
+func (c *Client) GetMetricData(ctx context.Context, applicationId string, metricPath string, start time.Time, end time.Time) ([]MetricData, error) {
+	var metricData []MetricData
+	query := url.Values{
+		"metric-path":     {metricPath},
+		"time-range-type": {"BETWEEN_TIMES"},
+		"start-time":      {strconv.FormatInt(start.UnixMilli(), 10)},
+		"end-time":        {strconv.FormatInt(end.UnixMilli(), 10)},
+		"rollup":          {"false"},
+		"output":          {"JSON"},
+	}
+	err := c.do(ctx, resty.MethodGet, "/controller/rest/applications/"+url.PathEscape(applicationId)+"/metric-data?"+query.Encode(), nil, &metricData)
+	return metricData, err
+}
+
+func (c *Client) ListActionSuppressions(ctx context.Context, applicationId string) ([]ActionSuppressionResponse, error) {
+	var actionSuppressions []ActionSuppressionResponse
+	err := c.do(ctx, resty.MethodGet, "/controller/alerting/rest/v1/applications/"+url.PathEscape(applicationId)+"/action-suppressions", nil, &actionSuppressions)
+	return actionSuppressions, err
+}
+
+func (c *Client) CreateActionSuppression(ctx context.Context, applicationId string, request ActionSuppressionRequest) (*ActionSuppressionResponse, error) {
+	var response ActionSuppressionResponse
+	if err := c.do(ctx, resty.MethodPost, "/controller/alerting/rest/v1/applications/"+url.PathEscape(applicationId)+"/action-suppressions", request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) DeleteActionSuppression(ctx context.Context, applicationId string, actionSuppressionId string) error {
+	return c.do(ctx, resty.MethodDelete, "/controller/alerting/rest/v1/applications/"+url.PathEscape(applicationId)+"/action-suppressions/"+url.PathEscape(actionSuppressionId), nil, nil)
+}
+
+var createdEventIdPattern = regexp.MustCompile(`event id:\s*(\d+)`)
+
+// PostEvent creates a custom event with the given, already encoded, query string. It returns the id of the created
+// event if the controller reported it.
+func (c *Client) PostEvent(ctx context.Context, applicationId string, query string) (string, error) {
+	res, err := c.execute(ctx, resty.MethodPost, "/controller/rest/applications/"+url.PathEscape(applicationId)+"/events", func(req *resty.Request) {
+		req.SetQueryString(query)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to post custom event: %w", err)
+	}
+	if !res.IsSuccess() {
+		return "", &StatusError{StatusCode: res.StatusCode(), Body: res.String()}
+	}
+
+	// The controller answers with "Successfully created the event id: <id>"
+	if match := createdEventIdPattern.FindStringSubmatch(res.String()); match != nil {
+		return match[1], nil
+	}
+	return "", nil
+}
+
+func (c *Client) do(ctx context.Context, method string, uri string, body any, result any) error {
+	// resty returns a nil/empty response when err != nil, so res is only read if err is nil.
+	res, err := c.execute(ctx, method, uri, func(req *resty.Request) {
+		if body != nil {
+			req.SetBody(body)
+		}
+		if result != nil {
+			req.SetResult(result)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to call %s %s: %w", method, uri, err)
+	}
+	if !res.IsSuccess() {
+		return &StatusError{StatusCode: res.StatusCode(), Body: res.String()}
+	}
+	return nil
+}
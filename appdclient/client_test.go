@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package appdclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	return New(resty.New().SetBaseURL(ts.URL))
+}
+
+func TestListApplications(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/controller/rest/applications", r.URL.Path)
+		require.Equal(t, "JSON", r.URL.Query().Get("output"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"id":1,"name":"checkout","accountGuid":"GUID-1"}]`))
+	})
+
+	applications, err := client.ListApplications(context.Background())
+	require.NoError(t, err)
+	require.Len(t, applications, 1)
+	require.Equal(t, "checkout", applications[0].Name)
+}
+
+func TestGetViolations(t *testing.T) {
+	start := time.UnixMilli(1000)
+	end := time.UnixMilli(2000)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/controller/rest/applications/42/problems/healthrule-violations", r.URL.Path)
+		require.Equal(t, "BETWEEN_TIMES", r.URL.Query().Get("time-range-type"))
+		require.Equal(t, "1000", r.URL.Query().Get("start-time"))
+		require.Equal(t, "2000", r.URL.Query().Get("end-time"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"id":7,"incidentStatus":"OPEN","affectedEntityDefinition":{"entityId":3}}]`))
+	})
+
+	violations, err := client.GetViolations(context.Background(), "42", start, end)
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	require.Equal(t, "OPEN", violations[0].IncidentStatus)
+}
+
+func TestGetMetricData(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/controller/rest/applications/Server & Infrastructure Monitoring/metric-data", r.URL.Path)
+		require.Equal(t, "Backends|*|Errors per Minute", r.URL.Query().Get("metric-path"))
+		require.Equal(t, "1000", r.URL.Query().Get("start-time"))
+		require.Equal(t, "false", r.URL.Query().Get("rollup"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"metricPath":"Backends|db|Errors per Minute","metricValues":[{"startTimeInMillis":1000,"value":3}]}]`))
+	})
+
+	metricData, err := client.GetMetricData(context.Background(), "Server & Infrastructure Monitoring", "Backends|*|Errors per Minute", time.UnixMilli(1000), time.UnixMilli(2000))
+	require.NoError(t, err)
+	require.Len(t, metricData, 1)
+	require.Equal(t, int64(3), metricData[0].MetricValues[0].Value)
+}
+
+func TestCreateAndDeleteActionSuppression(t *testing.T) {
+	var deleted string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			var request ActionSuppressionRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			require.Equal(t, "steadybit", request.Name)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":99,"name":"steadybit"}`))
+		case http.MethodDelete:
+			deleted = r.URL.Path
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	response, err := client.CreateActionSuppression(context.Background(), "42", ActionSuppressionRequest{Name: "steadybit"})
+	require.NoError(t, err)
+	require.Equal(t, 99, response.ID)
+
+	require.NoError(t, client.DeleteActionSuppression(context.Background(), "42", "99"))
+	require.Equal(t, "/controller/alerting/rest/v1/applications/42/action-suppressions/99", deleted)
+}
+
+func TestUnexpectedStatusCode(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	})
+
+	_, err := client.ListHealthRules(context.Background(), "42")
+	var statusError *StatusError
+	require.True(t, errors.As(err, &statusError))
+	require.Equal(t, http.StatusForbidden, statusError.StatusCode)
+}
+
+func TestPostEvent(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/controller/rest/applications/42/events", r.URL.Path)
+		require.Equal(t, "CUSTOM", r.URL.Query().Get("eventtype"))
+		_, _ = w.Write([]byte("Successfully created the event id: 12345"))
+	})
+
+	eventId, err := client.PostEvent(context.Background(), "42", "eventtype=CUSTOM")
+	require.NoError(t, err)
+	require.Equal(t, "12345", eventId)
+}
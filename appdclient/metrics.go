@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package appdclient
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	controllerRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "steadybit_appdynamics_controller_requests_total",
+		Help: "Number of requests sent to the AppDynamics controllers, including retries.",
+	}, []string{"controller", "method", "endpoint", "status"})
+	controllerRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "steadybit_appdynamics_controller_request_duration_seconds",
+		Help:    "Latency of the requests sent to the AppDynamics controllers.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"controller", "method", "endpoint"})
+	tokenRefreshes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "steadybit_appdynamics_token_refreshes_total",
+		Help: "Number of access tokens fetched for the API clients, by result.",
+	}, []string{"api_client", "result"})
+)
+
+// WithName sets the name of the controller the client calls, which labels the metrics of its requests.
+func WithName(name string) Option {
+	return func(c *Client) {
+		c.name = name
+	}
+}
+
+var idPathSegment = regexp.MustCompile(`/\d+(/|$)`)
+
+// getEndpoint returns the path of the uri with ids replaced, so that the endpoint can be used as metric label.
+func getEndpoint(uri string) string {
+	path, _, _ := strings.Cut(uri, "?")
+	return idPathSegment.ReplaceAllString(path, "/{id}$1")
+}
+
+func (c *Client) observeRequest(method string, uri string, res *resty.Response, err error, duration time.Duration) {
+	status := "error"
+	if err == nil && res != nil {
+		status = strconv.Itoa(res.StatusCode())
+	}
+	endpoint := getEndpoint(uri)
+	controllerRequests.WithLabelValues(c.name, method, endpoint, status).Inc()
+	controllerRequestDuration.WithLabelValues(c.name, method, endpoint).Observe(duration.Seconds())
+}
@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package appdclient
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEndpoint(t *testing.T) {
+	require.Equal(t, "/controller/rest/applications", getEndpoint("/controller/rest/applications?output=JSON"))
+	require.Equal(t, "/controller/alerting/rest/v1/applications/{id}/health-rules", getEndpoint("/controller/alerting/rest/v1/applications/42/health-rules?output=JSON"))
+	require.Equal(t, "/controller/alerting/rest/v1/applications/{id}/action-suppressions/{id}", getEndpoint("/controller/alerting/rest/v1/applications/42/action-suppressions/7"))
+}
+
+func TestRequestsAreCountedPerAttempt(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	})
+	WithRetryPolicy(testRetryPolicy)(client)
+	WithName("metrics-test")(client)
+
+	_, err := client.ListApplications(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1.0, testutil.ToFloat64(controllerRequests.WithLabelValues("metrics-test", http.MethodGet, "/controller/rest/applications", "503")))
+	require.Equal(t, 1.0, testutil.ToFloat64(controllerRequests.WithLabelValues("metrics-test", http.MethodGet, "/controller/rest/applications", "200")))
+}
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package appdclient
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// Credentials of an API client. The secret can be replaced at runtime, e.g. after it was rotated.
+type Credentials struct {
+	ApiClientName string
+	AccountName   string
+
+	mutex       sync.RWMutex
+	secret      string
+	tokenExpiry time.Time
+}
+
+func NewCredentials(apiClientName string, accountName string, secret string) *Credentials {
+	return &Credentials{ApiClientName: apiClientName, AccountName: accountName, secret: secret}
+}
+
+func (c *Credentials) Secret() string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.secret
+}
+
+// SetSecret replaces the secret and reports whether it changed.
+func (c *Credentials) SetSecret(secret string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.secret == secret {
+		return false
+	}
+	c.secret = secret
+	return true
+}
+
+// TokenExpiry returns when the current access token expires. It is zero if no token was fetched yet.
+func (c *Credentials) TokenExpiry() time.Time {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.tokenExpiry
+}
+
+func (c *Credentials) setTokenExpiry(expiry time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.tokenExpiry = expiry
+}
+
+// NewOAuthHttpClient returns an http client that authenticates all requests with an access token of the API client.
+// The token source is rebuilt whenever the secret of the credentials changes.
+func NewOAuthHttpClient(baseUrl string, credentials *Credentials, transport http.RoundTripper) *http.Client {
+	tokenUrl := fmt.Sprintf("%s/controller/api/oauth/access_token", strings.TrimRight(baseUrl, "/"))
+	tokenHttpClient := &http.Client{
+		Transport: &basicAuthTransport{
+			base:        transport,
+			credentials: credentials,
+			tokenURL:    tokenUrl,
+		},
+	}
+	return &http.Client{
+		Transport: &oauth2.Transport{
+			Source: &credentialsTokenSource{
+				ctx:         context.WithValue(context.Background(), oauth2.HTTPClient, tokenHttpClient),
+				tokenUrl:    tokenUrl,
+				credentials: credentials,
+			},
+			Base: transport,
+		},
+	}
+}
+
+type credentialsTokenSource struct {
+	ctx         context.Context
+	tokenUrl    string
+	credentials *Credentials
+
+	mutex       sync.Mutex
+	secret      string
+	source      oauth2.TokenSource
+	accessToken string
+}
+
+func (s *credentialsTokenSource) Token() (*oauth2.Token, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if secret := s.credentials.Secret(); s.source == nil || secret != s.secret {
+		oauth2ClientCredentials := clientcredentials.Config{
+			ClientID:     fmt.Sprintf("%s@%s", s.credentials.ApiClientName, s.credentials.AccountName),
+			ClientSecret: secret,
+			TokenURL:     s.tokenUrl,
+			AuthStyle:    oauth2.AuthStyleInParams,
+		}
+		s.source = oauth2ClientCredentials.TokenSource(s.ctx)
+		s.secret = secret
+	}
+	apiClient := s.credentials.ApiClientName + "@" + s.credentials.AccountName
+	token, err := s.source.Token()
+	if err != nil {
+		tokenRefreshes.WithLabelValues(apiClient, "failure").Inc()
+		return nil, err
+	}
+	// The source reuses a token until it expires, a new access token means it was refreshed.
+	if token.AccessToken != s.accessToken {
+		tokenRefreshes.WithLabelValues(apiClient, "success").Inc()
+		s.accessToken = token.AccessToken
+	}
+	s.credentials.setTokenExpiry(token.Expiry)
+	return token, nil
+}
+
+type basicAuthTransport struct {
+	base        http.RoundTripper
+	credentials *Credentials
+	tokenURL    string
+}
+
+func (t *basicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Add Basic Auth only to token requests
+	if req.URL.String() == t.tokenURL {
+		auth := base64.StdEncoding.EncodeToString([]byte(t.credentials.ApiClientName + ":" + t.credentials.Secret()))
+		req.Header.Set("Authorization", "Basic "+auth)
+	}
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package appdclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthHttpClientPicksUpRotatedSecret(t *testing.T) {
+	var tokenRequests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/controller/api/oauth/access_token" {
+			require.NoError(t, r.ParseForm())
+			require.Equal(t, "steadybit@acme", r.PostForm.Get("client_id"))
+			tokenRequests = append(tokenRequests, r.PostForm.Get("client_secret"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"token-` + r.PostForm.Get("client_secret") + `","token_type":"bearer","expires_in":3600}`))
+			return
+		}
+		require.Equal(t, "Bearer token-"+tokenRequests[len(tokenRequests)-1], r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+
+	credentials := NewCredentials("steadybit", "acme", "first")
+	client := New(resty.NewWithClient(NewOAuthHttpClient(ts.URL, credentials, http.DefaultTransport)).SetBaseURL(ts.URL))
+
+	for range 2 {
+		_, err := client.ListApplications(context.Background())
+		require.NoError(t, err)
+	}
+	require.Equal(t, []string{"first"}, tokenRequests)
+	require.WithinDuration(t, time.Now().Add(time.Hour), credentials.TokenExpiry(), time.Minute)
+
+	require.True(t, credentials.SetSecret("second"))
+	require.False(t, credentials.SetSecret("second"))
+	_, err := client.ListApplications(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"first", "second"}, tokenRequests)
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package appdclient
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// RetryPolicy controls how often failed requests are retried. Idempotent requests (GET, DELETE) are retried on
+// transport errors, 429 and 5xx responses, all other requests only on 429, as the controller did not process them.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one. Values below 2 disable retries.
+	MaxAttempts int
+	// WaitTime is the wait time before the first retry. It doubles with every further retry.
+	WaitTime time.Duration
+	// MaxWaitTime caps the wait time between two attempts, also if the controller asks for a longer Retry-After.
+	MaxWaitTime time.Duration
+	// Jitter randomizes the wait time by up to the given fraction (0-1) to spread the retries of concurrent calls.
+	Jitter float64
+}
+
+type Option func(*Client)
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithRateLimit limits the requests sent to the controller to the given number per second, allowing bursts of the
+// given size. Requests exceeding the limit wait for a free token. A limit of 0 disables the rate limiting.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(c *Client) {
+		if requestsPerSecond > 0 {
+			c.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), max(burst, 1))
+		}
+	}
+}
+
+func (c *Client) execute(ctx context.Context, method string, uri string, prepare func(*resty.Request)) (res *resty.Response, err error) {
+	ctx, span := c.startRequestSpan(ctx, method, uri)
+	attempt := 1
+	defer func() { endRequestSpan(span, attempt, res, err) }()
+
+	var bodyDigest string
+	if c.auditLog != nil && isMutating(method) {
+		defer func() { c.audit(ctx, method, uri, bodyDigest, res, err) }()
+	}
+
+	for ; ; attempt++ {
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		req := c.client.R().SetContext(ctx)
+		prepare(req)
+		if attempt == 1 && c.auditLog != nil && isMutating(method) {
+			bodyDigest = getBodyDigest(req)
+		}
+		injectTraceContext(ctx, req)
+		started := time.Now()
+		res, err = req.Execute(method, uri)
+		c.observeRequest(method, uri, res, err, time.Since(started))
+		if attempt >= c.retry.MaxAttempts || !isRetryable(method, res, err) || ctx.Err() != nil {
+			return res, err
+		}
+
+		wait := c.retry.backoff(attempt, res)
+		log.Debug().Err(err).Msgf("Retrying %s %s in %s (attempt %d of %d).", method, uri, wait, attempt+1, c.retry.MaxAttempts)
+		select {
+		case <-ctx.Done():
+			return res, err
+		case <-time.After(wait):
+		}
+	}
+}
+
+func isRetryable(method string, res *resty.Response, err error) bool {
+	if res != nil && res.StatusCode() == http.StatusTooManyRequests {
+		return true
+	}
+	if method != resty.MethodGet && method != resty.MethodDelete {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch res.StatusCode() {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (p RetryPolicy) backoff(attempt int, res *resty.Response) time.Duration {
+	wait := p.WaitTime << (attempt - 1)
+	if retryAfter, ok := getRetryAfter(res); ok {
+		wait = retryAfter
+	} else if p.Jitter > 0 {
+		wait += time.Duration(rand.Float64() * p.Jitter * float64(wait))
+	}
+	if wait < 0 || (p.MaxWaitTime > 0 && wait > p.MaxWaitTime) {
+		wait = p.MaxWaitTime
+	}
+	return wait
+}
+
+// getRetryAfter parses the Retry-After header, which is either a number of seconds or an HTTP date.
+func getRetryAfter(res *resty.Response) (time.Duration, bool) {
+	if res == nil {
+		return 0, false
+	}
+	value := res.Header().Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package appdclient
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testRetryPolicy = RetryPolicy{MaxAttempts: 3, WaitTime: time.Millisecond, MaxWaitTime: 10 * time.Millisecond}
+
+func TestRetriesIdempotentRequestsOnServiceUnavailable(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	})
+	WithRetryPolicy(testRetryPolicy)(client)
+
+	_, err := client.ListApplications(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(3), calls.Load())
+}
+
+func TestGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	WithRetryPolicy(testRetryPolicy)(client)
+
+	err := client.DeleteActionSuppression(context.Background(), "42", "99")
+	require.Error(t, err)
+	require.Equal(t, int32(3), calls.Load())
+}
+
+func TestRetriesPostOnlyOnTooManyRequests(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	WithRetryPolicy(testRetryPolicy)(client)
+
+	_, err := client.CreateActionSuppression(context.Background(), "42", ActionSuppressionRequest{Name: "steadybit"})
+	require.Error(t, err)
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestBackoffHonorsRetryAfter(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, WaitTime: 100 * time.Millisecond, MaxWaitTime: 5 * time.Second}
+
+	require.Equal(t, 100*time.Millisecond, policy.backoff(1, nil))
+	require.Equal(t, 200*time.Millisecond, policy.backoff(2, nil))
+	require.Equal(t, 5*time.Second, policy.backoff(20, nil))
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	res, err := client.client.R().Get("/")
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Second, policy.backoff(1, res))
+
+	policy.MaxWaitTime = time.Second
+	require.Equal(t, time.Second, policy.backoff(1, res))
+}
+
+func TestRateLimitDelaysRequests(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	})
+	WithRateLimit(20, 1)(client)
+
+	start := time.Now()
+	for range 3 {
+		_, err := client.ListApplications(context.Background())
+		require.NoError(t, err)
+	}
+	require.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package appdclient
+
+import (
+	"context"
+
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/steadybit/extension-appdynamics/appdclient")
+
+// startRequestSpan starts the span of a request to the controller, which covers all attempts of the request.
+func (c *Client) startRequestSpan(ctx context.Context, method string, uri string) (context.Context, trace.Span) {
+	endpoint := getEndpoint(uri)
+	return tracer.Start(ctx, method+" "+endpoint, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("appdynamics.controller", c.name),
+		attribute.String("http.request.method", method),
+		attribute.String("url.template", endpoint),
+	))
+}
+
+// injectTraceContext propagates the trace to the controller, e.g. to correlate requests in the logs of a proxy.
+func injectTraceContext(ctx context.Context, req *resty.Request) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
+
+func endRequestSpan(span trace.Span, attempts int, res *resty.Response, err error) {
+	span.SetAttributes(attribute.Int("http.request.resend_count", attempts-1))
+	if res != nil && res.RawResponse != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode()))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if res != nil && res.IsError() {
+		span.SetStatus(codes.Error, res.Status())
+	}
+	span.End()
+}
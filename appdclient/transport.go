@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package appdclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// TransportOptions configure how the controller is reached, e.g. through a corporate proxy with an internal CA.
+type TransportOptions struct {
+	// ProxyUrl is used for all requests to the controller. If empty, the HTTPS_PROXY and NO_PROXY environment
+	// variables apply.
+	ProxyUrl string
+	// NoProxy is a comma-separated list of hosts, domains and CIDRs that are reached without ProxyUrl, using the
+	// format of the NO_PROXY environment variable.
+	NoProxy string
+	// CaBundlePaths are PEM files whose certificates are trusted in addition to the system's root CAs.
+	CaBundlePaths []string
+	// ClientCertPath and ClientKeyPath are the PEM files of the client certificate presented to the controller.
+	ClientCertPath string
+	ClientKeyPath  string
+	// InsecureSkipVerify disables the verification of the controller's certificate. Only meant for lab setups.
+	InsecureSkipVerify bool
+}
+
+// NewTransport returns a transport for the token and API requests to the controller.
+func NewTransport(options TransportOptions) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if options.ProxyUrl != "" {
+		if _, err := url.Parse(options.ProxyUrl); err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		proxy := (&httpproxy.Config{
+			HTTPProxy:  options.ProxyUrl,
+			HTTPSProxy: options.ProxyUrl,
+			NoProxy:    options.NoProxy,
+		}).ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		}
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: options.InsecureSkipVerify,
+	}
+
+	if len(options.CaBundlePaths) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for _, caBundlePath := range options.CaBundlePaths {
+			pem, err := os.ReadFile(caBundlePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in CA bundle %s", caBundlePath)
+			}
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if options.ClientCertPath != "" || options.ClientKeyPath != "" {
+		certificate, err := tls.LoadX509KeyPair(options.ClientCertPath, options.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package appdclient
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportUsesProxyExceptForNoProxyHosts(t *testing.T) {
+	transport, err := NewTransport(TransportOptions{ProxyUrl: "http://proxy.corp:3128", NoProxy: "appd.internal,.example.com"})
+	require.NoError(t, err)
+
+	proxied, err := http.NewRequest(http.MethodGet, "https://acme.saas.appdynamics.com/controller", nil)
+	require.NoError(t, err)
+	proxyUrl, err := transport.Proxy(proxied)
+	require.NoError(t, err)
+	require.Equal(t, "http://proxy.corp:3128", proxyUrl.String())
+
+	direct, err := http.NewRequest(http.MethodGet, "https://controller.example.com/controller", nil)
+	require.NoError(t, err)
+	proxyUrl, err = transport.Proxy(direct)
+	require.NoError(t, err)
+	require.Nil(t, proxyUrl)
+}
+
+func TestTransportTrustsCaBundle(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+
+	caBundlePath := filepath.Join(t.TempDir(), "ca.pem")
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caBundlePath, caBundle, 0o600))
+
+	untrusted, err := NewTransport(TransportOptions{})
+	require.NoError(t, err)
+	_, err = New(resty.New().SetTransport(untrusted).SetBaseURL(ts.URL)).ListApplications(context.Background())
+	require.Error(t, err)
+
+	trusted, err := NewTransport(TransportOptions{CaBundlePaths: []string{caBundlePath}})
+	require.NoError(t, err)
+	_, err = New(resty.New().SetTransport(trusted).SetBaseURL(ts.URL)).ListApplications(context.Background())
+	require.NoError(t, err)
+}
+
+func TestTransportRejectsInvalidFiles(t *testing.T) {
+	_, err := NewTransport(TransportOptions{CaBundlePaths: []string{filepath.Join(t.TempDir(), "missing.pem")}})
+	require.ErrorContains(t, err, "failed to read CA bundle")
+
+	emptyBundlePath := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(emptyBundlePath, []byte("no certificate"), 0o600))
+	_, err = NewTransport(TransportOptions{CaBundlePaths: []string{emptyBundlePath}})
+	require.ErrorContains(t, err, "no certificates found")
+
+	_, err = NewTransport(TransportOptions{ClientCertPath: emptyBundlePath, ClientKeyPath: emptyBundlePath})
+	require.ErrorContains(t, err, "failed to load client certificate")
+}
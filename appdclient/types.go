@@ -5,7 +5,7 @@
 // SPDX-License-Identifier: MIT
 // SPDX-FileCopyrightText: 2025 Steadybit GmbH
 
-package extappdynamics
+package appdclient
 
 type Application struct {
 	ID          int    `json:"id"`
@@ -66,6 +66,69 @@ type Affects struct {
 	AffectedInfoType string `json:"affectedInfoType"`
 }
 
+// Machine is a machine monitored by Server Visibility (SIM).
+type Machine struct {
+	ID         int64               `json:"id"`
+	Name       string              `json:"name"`
+	HostID     string              `json:"hostId"`
+	SimEnabled bool                `json:"simEnabled"`
+	Properties map[string]string   `json:"properties"`
+	Tags       map[string][]string `json:"tags"`
+	Cpus       []MachineCpu        `json:"cpus"`
+}
+
+type MachineCpu struct {
+	Cores             int `json:"cores"`
+	LogicalProcessors int `json:"logicalProcessors"`
+}
+
+// DatabaseCollector collects the metrics of a database monitored by Database Visibility.
+type DatabaseCollector struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Hostname  string `json:"hostname"`
+	Port      int    `json:"port"`
+	AgentName string `json:"agentName"`
+	Enabled   bool   `json:"enabled"`
+}
+
+// Backend is a dependency the tiers of an application call, e.g. an HTTP service, a database via JDBC or a message
+// queue.
+type Backend struct {
+	ID                         int64             `json:"id"`
+	Name                       string            `json:"name"`
+	ExitPointType              string            `json:"exitPointType"`
+	TierID                     int64             `json:"tierId"`
+	ApplicationComponentNodeID int64             `json:"applicationComponentNodeId"`
+	Properties                 []BackendProperty `json:"properties"`
+}
+
+type BackendProperty struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// MetricData are the values of a metric, one per minute unless rolled up.
+type MetricData struct {
+	MetricID     int64         `json:"metricId"`
+	MetricName   string        `json:"metricName"`
+	MetricPath   string        `json:"metricPath"`
+	Frequency    string        `json:"frequency"`
+	MetricValues []MetricValue `json:"metricValues"`
+}
+
+type MetricValue struct {
+	StartTimeInMillis int64 `json:"startTimeInMillis"`
+	Value             int64 `json:"value"`
+	Min               int64 `json:"min"`
+	Max               int64 `json:"max"`
+	Current           int64 `json:"current"`
+	Sum               int64 `json:"sum"`
+	Count             int64 `json:"count"`
+}
+
 type TokenResponse struct {
 	AccessToken string `json:"access_token"`
 	ExpiresIn   int64  `json:"expires_in"`
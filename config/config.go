@@ -8,6 +8,10 @@
 package config
 
 import (
+	"path"
+	"slices"
+	"time"
+
 	"github.com/kelseyhightower/envconfig"
 	"github.com/rs/zerolog/log"
 )
@@ -17,22 +21,103 @@ import (
 // https://github.com/kelseyhightower/envconfig
 type Specification struct {
 	// Deprecated: AccessToken is no longer supported. Use apiClientName, apiClientSecret, and accountName instead.
-	AccessToken                             string   `json:"accessToken" split_words:"true" required:"false"`
-	ApiBaseUrl                              string   `json:"apiBaseUrl" split_words:"true" required:"true"`
-	ApiClientName                           string   `json:"apiClientName" split_words:"true" required:"false"`
-	ApiClientSecret                         string   `json:"apiClientSecret" split_words:"true" required:"false"`
-	AccountName                             string   `json:"accountName" split_words:"true" required:"false"`
-	EventApplicationID                      string   `json:"eventApplicationID" split_words:"true" required:"false"`
-	ActionSuppressionTimezone               string   `json:"actionSuppressionTimezone" split_words:"true" required:"false"`
-	DiscoveryAttributesExcludesApplications []string `json:"discoveryAttributesExcludesApplications" split_words:"true" required:"false"`
-	DiscoveryAttributesExcludesHealthRules  []string `json:"discoveryAttributesExcludesHealthRules" split_words:"true" required:"false"`
-	ApplicationFilter                       []string `json:"applicationFilter" split_words:"true" required:"false"`
+	AccessToken                             string                   `json:"accessToken" split_words:"true" required:"false"`
+	ApiBaseUrl                              string                   `json:"apiBaseUrl" split_words:"true" required:"false"`
+	ApiClientName                           string                   `json:"apiClientName" split_words:"true" required:"false"`
+	ApiClientSecret                         string                   `json:"apiClientSecret" split_words:"true" required:"false"`
+	ApiClientSecretFile                     string                   `json:"apiClientSecretFile" split_words:"true" required:"false"`
+	AccessTokenFile                         string                   `json:"accessTokenFile" split_words:"true" required:"false"`
+	AccountName                             string                   `json:"accountName" split_words:"true" required:"false"`
+	EventApplicationID                      string                   `json:"eventApplicationID" split_words:"true" required:"false"`
+	ActionSuppressionTimezone               string                   `json:"actionSuppressionTimezone" split_words:"true" required:"false"`
+	DiscoveryAttributesExcludesApplications []string                 `json:"discoveryAttributesExcludesApplications" split_words:"true" required:"false"`
+	DiscoveryAttributesExcludesHealthRules  []string                 `json:"discoveryAttributesExcludesHealthRules" split_words:"true" required:"false"`
+	DiscoveryIntervalApplications           time.Duration            `json:"discoveryIntervalApplications" split_words:"true" required:"false" default:"1m"`
+	DiscoveryIntervalHealthRules            time.Duration            `json:"discoveryIntervalHealthRules" split_words:"true" required:"false" default:"1m"`
+	DiscoveryIntervalMachines               time.Duration            `json:"discoveryIntervalMachines" split_words:"true" required:"false" default:"1m"`
+	DiscoveryIntervalDatabases              time.Duration            `json:"discoveryIntervalDatabases" split_words:"true" required:"false" default:"1m"`
+	DiscoveryIntervalBackends               time.Duration            `json:"discoveryIntervalBackends" split_words:"true" required:"false" default:"1m"`
+	ApplicationCacheTtl                     time.Duration            `json:"applicationCacheTtl" split_words:"true" required:"false" default:"30s"`
+	HealthRuleDiscoveryConcurrency          int                      `json:"healthRuleDiscoveryConcurrency" split_words:"true" required:"false" default:"4"`
+	BackendDiscoveryConcurrency             int                      `json:"backendDiscoveryConcurrency" split_words:"true" required:"false" default:"4"`
+	DiscoveryFailIfEmpty                    bool                     `json:"discoveryFailIfEmpty" split_words:"true" required:"false"`
+	TracingOtlpEndpoint                     string                   `json:"tracingOtlpEndpoint" split_words:"true" required:"false"`
+	TracingSampleRatio                      float64                  `json:"tracingSampleRatio" split_words:"true" required:"false" default:"1"`
+	AuditLog                                string                   `json:"auditLog" split_words:"true" required:"false"`
+	DryRun                                  bool                     `json:"dryRun" split_words:"true" required:"false"`
+	ActionStateDir                          string                   `json:"actionStateDir" split_words:"true" required:"false"`
+	DisabledDiscoveries                     []string                 `json:"disabledDiscoveries" split_words:"true" required:"false"`
+	DisabledActions                         []string                 `json:"disabledActions" split_words:"true" required:"false"`
+	ApplicationFilter                       []string                 `json:"applicationFilter" split_words:"true" required:"false"`
+	ApplicationNameIncludes                 NamePatterns             `json:"applicationNameIncludes" split_words:"true" required:"false"`
+	ApplicationNameExcludes                 NamePatterns             `json:"applicationNameExcludes" split_words:"true" required:"false"`
+	ApplicationAccountGuids                 []string                 `json:"applicationAccountGuids" split_words:"true" required:"false"`
+	Controllers                             ControllerSpecifications `json:"controllers" split_words:"true" required:"false"`
+	EventTargetAttributeIncludes            []string                 `json:"eventTargetAttributeIncludes" split_words:"true" required:"false"`
+	EventTargetAttributeExcludes            []string                 `json:"eventTargetAttributeExcludes" split_words:"true" required:"false"`
+	EventTargetAttributeRenames             map[string]string        `json:"eventTargetAttributeRenames" split_words:"true" required:"false"`
+	EventTargetAttributeMaxProperties       int                      `json:"eventTargetAttributeMaxProperties" split_words:"true" required:"false" default:"25"`
+	AnalyticsEventsApiUrl                   string                   `json:"analyticsEventsApiUrl" split_words:"true" required:"false"`
+	AnalyticsGlobalAccountName              string                   `json:"analyticsGlobalAccountName" split_words:"true" required:"false"`
+	AnalyticsApiKey                         string                   `json:"analyticsApiKey" split_words:"true" required:"false"`
+	AnalyticsSchemaName                     string                   `json:"analyticsSchemaName" split_words:"true" required:"false" default:"steadybit_experiments"`
+	EventSinks                              []string                 `json:"eventSinks" split_words:"true" required:"false"`
+	EventSinkFilters                        map[string]string        `json:"eventSinkFilters" split_words:"true" required:"false"`
+	EventLogFilePath                        string                   `json:"eventLogFilePath" split_words:"true" required:"false"`
+	EventWebhookUrl                         string                   `json:"eventWebhookUrl" split_words:"true" required:"false"`
+	EventWebhookHeaders                     map[string]string        `json:"eventWebhookHeaders" split_words:"true" required:"false"`
+	EventCorrelationLink                    bool                     `json:"eventCorrelationLink" split_words:"true" required:"false"`
+	EventDeduplicationWindow                time.Duration            `json:"eventDeduplicationWindow" split_words:"true" required:"false" default:"5m"`
+	ControllerRetryMaxAttempts              int                      `json:"controllerRetryMaxAttempts" split_words:"true" required:"false" default:"3"`
+	ControllerRetryWaitTime                 time.Duration            `json:"controllerRetryWaitTime" split_words:"true" required:"false" default:"500ms"`
+	ControllerRetryMaxWaitTime              time.Duration            `json:"controllerRetryMaxWaitTime" split_words:"true" required:"false" default:"10s"`
+	ControllerRetryJitter                   float64                  `json:"controllerRetryJitter" split_words:"true" required:"false" default:"0.2"`
+	ControllerRateLimit                     float64                  `json:"controllerRateLimit" split_words:"true" required:"false" default:"10"`
+	ControllerRateLimitBurst                int                      `json:"controllerRateLimitBurst" split_words:"true" required:"false" default:"20"`
+	ControllerProxyUrl                      string                   `json:"controllerProxyUrl" split_words:"true" required:"false"`
+	ControllerNoProxy                       string                   `json:"controllerNoProxy" split_words:"true" required:"false"`
+	ControllerCaBundlePaths                 []string                 `json:"controllerCaBundlePaths" split_words:"true" required:"false"`
+	ControllerClientCertPath                string                   `json:"controllerClientCertPath" split_words:"true" required:"false"`
+	ControllerClientKeyPath                 string                   `json:"controllerClientKeyPath" split_words:"true" required:"false"`
+	ControllerInsecureSkipVerify            bool                     `json:"controllerInsecureSkipVerify" split_words:"true" required:"false"`
+	ConfigFile                              string                   `json:"configFile" split_words:"true" required:"false"`
+	ConfigReloadInterval                    time.Duration            `json:"configReloadInterval" split_words:"true" required:"false" default:"30s"`
+	ConnectivityProbeInterval               time.Duration            `json:"connectivityProbeInterval" split_words:"true" required:"false" default:"1m"`
+	ConnectivityReadiness                   string                   `json:"connectivityReadiness" split_words:"true" required:"false" default:"any"`
 }
 
 var (
 	Config Specification
 )
 
+// The names of the discoveries and actions that can be disabled through DisabledDiscoveries and DisabledActions.
+const (
+	DiscoveryApplication    = "application"
+	DiscoveryHealthRule     = "health-rule"
+	DiscoveryMachine        = "machine"
+	DiscoveryDatabase       = "database"
+	DiscoveryBackend        = "backend"
+	ActionHealthRuleCheck   = "health-rule-check"
+	ActionActionSuppression = "action-suppression"
+	ActionPermissionCheck   = "permission-check"
+	ActionMachineCheck      = "machine-check"
+	ActionDatabaseCheck     = "database-check"
+	ActionBackendCheck      = "backend-check"
+)
+
+var (
+	discoveries = []string{DiscoveryApplication, DiscoveryHealthRule, DiscoveryMachine, DiscoveryDatabase, DiscoveryBackend}
+	actions     = []string{ActionHealthRuleCheck, ActionActionSuppression, ActionPermissionCheck, ActionMachineCheck, ActionDatabaseCheck, ActionBackendCheck}
+)
+
+func IsDiscoveryEnabled(name string) bool {
+	return !slices.Contains(Config.DisabledDiscoveries, name)
+}
+
+func IsActionEnabled(name string) bool {
+	return !slices.Contains(Config.DisabledActions, name)
+}
+
 func ParseConfiguration() {
 	err := envconfig.Process("steadybit_extension", &Config)
 	if err != nil {
@@ -41,13 +126,62 @@ func ParseConfiguration() {
 }
 
 func ValidateConfiguration() {
-	if Config.AccessToken != "" {
-		log.Warn().Msg("Setting up an access token is deprecated. Please use apiClientName, apiClientSecret and accountName instead.")
-	} else if Config.ApiClientName == "" || Config.ApiClientSecret == "" || Config.AccountName == "" {
-		log.Fatal().Msg("ApiClientName, ApiClientSecret and AccountName must be set in the configuration.")
+	validateControllers()
+
+	if err := ReloadConfigFile(); err != nil {
+		log.Fatal().Err(err).Msgf("Failed to apply the settings of config file '%s'.", Config.ConfigFile)
+	}
+
+	if Config.AnalyticsEventsApiUrl != "" && (Config.AnalyticsGlobalAccountName == "" || Config.AnalyticsApiKey == "") {
+		log.Fatal().Msg("AnalyticsGlobalAccountName and AnalyticsApiKey must be set when AnalyticsEventsApiUrl is configured.")
+	}
+
+	for _, sink := range Config.EventSinks {
+		switch {
+		case sink == "customEvent" && Config.EventApplicationID == "":
+			log.Fatal().Msg("EventApplicationID must be set when the customEvent event sink is enabled.")
+		case sink == "analytics" && Config.AnalyticsEventsApiUrl == "":
+			log.Fatal().Msg("AnalyticsEventsApiUrl must be set when the analytics event sink is enabled.")
+		case sink == "logFile" && Config.EventLogFilePath == "":
+			log.Fatal().Msg("EventLogFilePath must be set when the logFile event sink is enabled.")
+		case sink == "webhook" && Config.EventWebhookUrl == "":
+			log.Fatal().Msg("EventWebhookUrl must be set when the webhook event sink is enabled.")
+		}
+	}
+
+	if (Config.ControllerClientCertPath == "") != (Config.ControllerClientKeyPath == "") {
+		log.Fatal().Msg("ControllerClientCertPath and ControllerClientKeyPath must be set together.")
+	}
+	if Config.ControllerInsecureSkipVerify {
+		log.Warn().Msg("The certificates of the AppDynamics controllers are not verified. Do not use ControllerInsecureSkipVerify in production.")
+	}
+
+	if !slices.Contains([]string{"all", "any", "ignore"}, Config.ConnectivityReadiness) {
+		log.Fatal().Msgf("ConnectivityReadiness must be one of 'all', 'any' or 'ignore', but is '%s'.", Config.ConnectivityReadiness)
+	}
+
+	for _, discovery := range Config.DisabledDiscoveries {
+		if !slices.Contains(discoveries, discovery) {
+			log.Fatal().Msgf("Unknown discovery '%s' in DisabledDiscoveries, known discoveries are %v.", discovery, discoveries)
+		}
+	}
+	for _, action := range Config.DisabledActions {
+		if !slices.Contains(actions, action) {
+			log.Fatal().Msgf("Unknown action '%s' in DisabledActions, known actions are %v.", action, actions)
+		}
+	}
+
+	if Config.TracingSampleRatio < 0 || Config.TracingSampleRatio > 1 {
+		log.Fatal().Msgf("TracingSampleRatio must be between 0 and 1, but is %v.", Config.TracingSampleRatio)
+	}
+
+	if Config.ControllerRetryJitter < 0 || Config.ControllerRetryJitter > 1 {
+		log.Fatal().Msg("ControllerRetryJitter must be between 0 and 1.")
 	}
 
-	if len(Config.ApplicationFilter) > 0 {
-		log.Info().Strs("ApplicationFilter", Config.ApplicationFilter).Msg("Using ApplicationFilter to limit the applications that are discovered. If you want to discover all applications, set ApplicationFilter to an empty list.")
+	for _, pattern := range slices.Concat(Config.EventTargetAttributeIncludes, Config.EventTargetAttributeExcludes) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			log.Fatal().Err(err).Msgf("Invalid target attribute pattern '%s'.", pattern)
+		}
 	}
 }
@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoveriesAndActionsCanBeDisabled(t *testing.T) {
+	Config = Specification{
+		DisabledDiscoveries: []string{DiscoveryHealthRule},
+		DisabledActions:     []string{ActionActionSuppression},
+	}
+	t.Cleanup(func() { Config = Specification{} })
+
+	require.True(t, IsDiscoveryEnabled(DiscoveryApplication))
+	require.False(t, IsDiscoveryEnabled(DiscoveryHealthRule))
+	require.True(t, IsActionEnabled(ActionHealthRuleCheck))
+	require.False(t, IsActionEnabled(ActionActionSuppression))
+}
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package config
+
+import (
+	"encoding/json"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultControllerName is the name of the controller configured through the top-level ApiBaseUrl and credentials.
+const DefaultControllerName = "default"
+
+// ControllerSpecification describes the connection to a single AppDynamics controller.
+type ControllerSpecification struct {
+	Name              string   `json:"name"`
+	ApiBaseUrl        string   `json:"apiBaseUrl"`
+	ApiClientName     string   `json:"apiClientName"`
+	ApiClientSecret   string   `json:"apiClientSecret"`
+	AccountName       string   `json:"accountName"`
+	AccessToken       string   `json:"accessToken"`
+	ApplicationFilter []string `json:"applicationFilter"`
+	// ApplicationNameIncludes, ApplicationNameExcludes and ApplicationAccountGuids further limit the discovered
+	// applications by name and account.
+	ApplicationNameIncludes NamePatterns `json:"applicationNameIncludes"`
+	ApplicationNameExcludes NamePatterns `json:"applicationNameExcludes"`
+	ApplicationAccountGuids []string     `json:"applicationAccountGuids"`
+	// ApiClientSecretFile and AccessTokenFile are read instead of ApiClientSecret and AccessToken if set. They are
+	// re-read at runtime, so that rotated secrets are picked up without a restart.
+	ApiClientSecretFile string `json:"apiClientSecretFile"`
+	AccessTokenFile     string `json:"accessTokenFile"`
+}
+
+// ReadSecret returns the current API client secret, or the access token if the controller is set up with one.
+func (c ControllerSpecification) ReadSecret() (string, error) {
+	if c.UsesAccessToken() {
+		return ReadSecret(c.AccessToken, c.AccessTokenFile)
+	}
+	return ReadSecret(c.ApiClientSecret, c.ApiClientSecretFile)
+}
+
+func (c ControllerSpecification) UsesAccessToken() bool {
+	return c.AccessToken != "" || c.AccessTokenFile != ""
+}
+
+// ControllerSpecifications is read from a JSON array, e.g.
+// [{"name":"saas","apiBaseUrl":"https://acme.saas.appdynamics.com","apiClientName":"steadybit","apiClientSecret":"...","accountName":"acme"}]
+type ControllerSpecifications []ControllerSpecification
+
+// Decode implements envconfig.Decoder
+func (c *ControllerSpecifications) Decode(value string) error {
+	return json.Unmarshal([]byte(value), c)
+}
+
+// GetControllers returns all configured controllers. The top-level ApiBaseUrl and credentials form the controller
+// named "default", which always comes first if it is configured.
+func GetControllers() []ControllerSpecification {
+	controllers := make([]ControllerSpecification, 0, len(Config.Controllers)+1)
+	if Config.ApiBaseUrl != "" {
+		controllers = append(controllers, ControllerSpecification{
+			Name:                    DefaultControllerName,
+			ApiBaseUrl:              Config.ApiBaseUrl,
+			ApiClientName:           Config.ApiClientName,
+			ApiClientSecret:         Config.ApiClientSecret,
+			AccountName:             Config.AccountName,
+			AccessToken:             Config.AccessToken,
+			ApplicationFilter:       Config.ApplicationFilter,
+			ApplicationNameIncludes: Config.ApplicationNameIncludes,
+			ApplicationNameExcludes: Config.ApplicationNameExcludes,
+			ApplicationAccountGuids: Config.ApplicationAccountGuids,
+			ApiClientSecretFile:     Config.ApiClientSecretFile,
+			AccessTokenFile:         Config.AccessTokenFile,
+		})
+	}
+	return append(controllers, Config.Controllers...)
+}
+
+func validateControllers() {
+	controllers := GetControllers()
+	if len(controllers) == 0 {
+		log.Fatal().Msg("Either ApiBaseUrl or Controllers must be set in the configuration.")
+	}
+
+	names := make(map[string]bool, len(controllers))
+	for _, controller := range controllers {
+		if controller.Name == "" {
+			log.Fatal().Msgf("The controller with url '%s' has no name.", controller.ApiBaseUrl)
+		}
+		if names[controller.Name] {
+			log.Fatal().Msgf("The controller name '%s' is used more than once.", controller.Name)
+		}
+		names[controller.Name] = true
+
+		if controller.ApiBaseUrl == "" {
+			log.Fatal().Msgf("ApiBaseUrl must be set for controller '%s'.", controller.Name)
+		}
+		if controller.UsesAccessToken() {
+			log.Warn().Msgf("Setting up an access token for controller '%s' is deprecated. Please use apiClientName, apiClientSecret and accountName instead.", controller.Name)
+		} else if controller.ApiClientName == "" || (controller.ApiClientSecret == "" && controller.ApiClientSecretFile == "") || controller.AccountName == "" {
+			log.Fatal().Msgf("ApiClientName, ApiClientSecret (or ApiClientSecretFile) and AccountName must be set for controller '%s'.", controller.Name)
+		}
+		if _, err := controller.ReadSecret(); err != nil {
+			log.Fatal().Err(err).Msgf("Failed to read the secret of controller '%s'.", controller.Name)
+		}
+		if len(controller.ApplicationFilter) > 0 {
+			log.Info().Strs("ApplicationFilter", controller.ApplicationFilter).Msgf("Using ApplicationFilter to limit the applications that are discovered from controller '%s'. If you want to discover all applications, set ApplicationFilter to an empty list.", controller.Name)
+		}
+		if len(controller.ApplicationNameIncludes) > 0 || len(controller.ApplicationNameExcludes) > 0 || len(controller.ApplicationAccountGuids) > 0 {
+			log.Info().
+				Strs("ApplicationNameIncludes", controller.ApplicationNameIncludes.Strings()).
+				Strs("ApplicationNameExcludes", controller.ApplicationNameExcludes.Strings()).
+				Strs("ApplicationAccountGuids", controller.ApplicationAccountGuids).
+				Msgf("Limiting the applications that are discovered from controller '%s' by name and account.", controller.Name)
+		}
+	}
+}
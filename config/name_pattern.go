@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// NamePattern matches names either as glob, e.g. "checkout-*", or, if enclosed in slashes, as regular expression, e.g.
+// "/^checkout-(eu|us)$/". Globs support "*" for any number of characters and "?" for a single character.
+type NamePattern struct {
+	pattern string
+	regexp  *regexp.Regexp
+}
+
+func ParseNamePattern(pattern string) (NamePattern, error) {
+	var expression string
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		expression = pattern[1 : len(pattern)-1]
+	} else {
+		expression = "^" + strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(pattern)) + "$"
+	}
+	compiled, err := regexp.Compile(expression)
+	if err != nil {
+		return NamePattern{}, fmt.Errorf("invalid name pattern '%s': %w", pattern, err)
+	}
+	return NamePattern{pattern: pattern, regexp: compiled}, nil
+}
+
+func (p NamePattern) Matches(name string) bool {
+	return p.regexp.MatchString(name)
+}
+
+func (p NamePattern) String() string {
+	return p.pattern
+}
+
+// NamePatterns are read from a comma-separated list in the environment or from a JSON array of strings.
+type NamePatterns []NamePattern
+
+// Decode implements envconfig.Decoder
+func (p *NamePatterns) Decode(value string) error {
+	if value == "" {
+		*p = nil
+		return nil
+	}
+	return p.parse(strings.Split(value, ","))
+}
+
+func (p *NamePatterns) UnmarshalJSON(data []byte) error {
+	var patterns []string
+	if err := json.Unmarshal(data, &patterns); err != nil {
+		return err
+	}
+	return p.parse(patterns)
+}
+
+func (p *NamePatterns) parse(patterns []string) error {
+	parsed := make(NamePatterns, 0, len(patterns))
+	for _, pattern := range patterns {
+		namePattern, err := ParseNamePattern(strings.TrimSpace(pattern))
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, namePattern)
+	}
+	*p = parsed
+	return nil
+}
+
+// MatchesAny reports whether any of the patterns matches the name.
+func (p NamePatterns) MatchesAny(name string) bool {
+	for _, pattern := range p {
+		if pattern.Matches(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p NamePatterns) Strings() []string {
+	patterns := make([]string, 0, len(p))
+	for _, pattern := range p {
+		patterns = append(patterns, pattern.String())
+	}
+	return patterns
+}
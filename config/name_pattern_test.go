@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		matches bool
+	}{
+		{"checkout-*", "checkout-eu", true},
+		{"checkout-*", "legacy-checkout-eu", false},
+		{"checkout-?", "checkout-1", true},
+		{"checkout-?", "checkout-12", false},
+		{"shop.prod", "shopXprod", false},
+		{"team/*", "team/payments", true},
+		{"/^checkout-(eu|us)$/", "checkout-us", true},
+		{"/^checkout-(eu|us)$/", "checkout-apac", false},
+		{"/payments/", "legacy-payments-v2", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			pattern, err := ParseNamePattern(tt.pattern)
+			require.NoError(t, err)
+			require.Equal(t, tt.matches, pattern.Matches(tt.name))
+		})
+	}
+
+	_, err := ParseNamePattern("/checkout-(/")
+	require.Error(t, err)
+}
+
+func TestNamePatterns_Decode(t *testing.T) {
+	var patterns NamePatterns
+	require.NoError(t, patterns.Decode("checkout-*, /^ledger$/"))
+	require.Equal(t, []string{"checkout-*", "/^ledger$/"}, patterns.Strings())
+	require.True(t, patterns.MatchesAny("ledger"))
+	require.False(t, patterns.MatchesAny("ledger-v2"))
+
+	var controllers ControllerSpecifications
+	require.NoError(t, json.Unmarshal([]byte(`[{"name":"onprem","applicationNameExcludes":["*-test"]}]`), &controllers))
+	require.True(t, controllers[0].ApplicationNameExcludes.MatchesAny("checkout-test"))
+
+	require.Error(t, json.Unmarshal([]byte(`[{"name":"onprem","applicationNameIncludes":["/(/"]}]`), &controllers))
+}
@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ReloadableSpecification are the settings of the ConfigFile. They are reloaded at runtime, settings missing in the
+// file keep the values configured through the environment.
+type ReloadableSpecification struct {
+	ApplicationFilter            *[]string           `json:"applicationFilter"`
+	ControllerApplicationFilters map[string][]string `json:"controllerApplicationFilters"`
+	// ReloadableApplicationSelection applies to the default controller, ControllerApplicationSelections to the
+	// controllers by name.
+	ReloadableApplicationSelection
+	ControllerApplicationSelections         map[string]ReloadableApplicationSelection `json:"controllerApplicationSelections"`
+	DiscoveryAttributesExcludesApplications *[]string                                 `json:"discoveryAttributesExcludesApplications"`
+	DiscoveryAttributesExcludesHealthRules  *[]string                                 `json:"discoveryAttributesExcludesHealthRules"`
+}
+
+// ReloadableApplicationSelection are the name and account filters of a controller in the ConfigFile.
+type ReloadableApplicationSelection struct {
+	ApplicationNameIncludes *NamePatterns `json:"applicationNameIncludes"`
+	ApplicationNameExcludes *NamePatterns `json:"applicationNameExcludes"`
+	ApplicationAccountGuids *[]string     `json:"applicationAccountGuids"`
+}
+
+// ApplicationSelection are the name and account filters limiting the applications discovered from a controller.
+type ApplicationSelection struct {
+	NameIncludes NamePatterns
+	NameExcludes NamePatterns
+	AccountGuids []string
+}
+
+var (
+	reloaded atomic.Pointer[ReloadableSpecification]
+	// reloadMutex guards configFileData, as the config file is reloaded at startup as well as by the reload goroutine.
+	reloadMutex    sync.Mutex
+	configFileData []byte
+)
+
+// ReloadConfigFile reads the ConfigFile and applies its settings if the file changed since it was read last.
+func ReloadConfigFile() error {
+	if Config.ConfigFile == "" {
+		return nil
+	}
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+	data, err := os.ReadFile(Config.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if configFileData != nil && bytes.Equal(data, configFileData) {
+		return nil
+	}
+
+	var spec ReloadableSpecification
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+	reloaded.Store(&spec)
+	configFileData = data
+	log.Info().Str("file", Config.ConfigFile).Msg("Applied settings from config file.")
+	return nil
+}
+
+// GetApplicationFilter returns the application filter of the given controller, the ConfigFile taking precedence over
+// the given filter from the environment.
+func GetApplicationFilter(controllerName string, filter []string) []string {
+	if spec := reloaded.Load(); spec != nil {
+		if controllerFilter, ok := spec.ControllerApplicationFilters[controllerName]; ok {
+			return controllerFilter
+		}
+		if controllerName == DefaultControllerName && spec.ApplicationFilter != nil {
+			return *spec.ApplicationFilter
+		}
+	}
+	return filter
+}
+
+// GetApplicationSelection returns the name and account filters of the given controller, the ConfigFile taking precedence
+// over the given filters from the environment.
+func GetApplicationSelection(controllerName string, selection ApplicationSelection) ApplicationSelection {
+	spec := reloaded.Load()
+	if spec == nil {
+		return selection
+	}
+	if controllerName == DefaultControllerName {
+		selection = spec.ReloadableApplicationSelection.apply(selection)
+	}
+	if controllerSelection, ok := spec.ControllerApplicationSelections[controllerName]; ok {
+		selection = controllerSelection.apply(selection)
+	}
+	return selection
+}
+
+func (s ReloadableApplicationSelection) apply(selection ApplicationSelection) ApplicationSelection {
+	if s.ApplicationNameIncludes != nil {
+		selection.NameIncludes = *s.ApplicationNameIncludes
+	}
+	if s.ApplicationNameExcludes != nil {
+		selection.NameExcludes = *s.ApplicationNameExcludes
+	}
+	if s.ApplicationAccountGuids != nil {
+		selection.AccountGuids = *s.ApplicationAccountGuids
+	}
+	return selection
+}
+
+func GetDiscoveryAttributesExcludesApplications() []string {
+	if spec := reloaded.Load(); spec != nil && spec.DiscoveryAttributesExcludesApplications != nil {
+		return *spec.DiscoveryAttributesExcludesApplications
+	}
+	return Config.DiscoveryAttributesExcludesApplications
+}
+
+func GetDiscoveryAttributesExcludesHealthRules() []string {
+	if spec := reloaded.Load(); spec != nil && spec.DiscoveryAttributesExcludesHealthRules != nil {
+		return *spec.DiscoveryAttributesExcludesHealthRules
+	}
+	return Config.DiscoveryAttributesExcludesHealthRules
+}
+
+// ReadSecret returns the content of the file, e.g. a mounted Kubernetes secret, or the value if no file is given.
+func ReadSecret(value string, file string) (string, error) {
+	if file == "" {
+		return value, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// StartReloading calls reload every ConfigReloadInterval and whenever the extension receives SIGHUP.
+func StartReloading(reload func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	var ticks <-chan time.Time
+	if Config.ConfigReloadInterval > 0 {
+		ticks = time.NewTicker(Config.ConfigReloadInterval).C
+	}
+
+	go func() {
+		for {
+			select {
+			case <-signals:
+				log.Info().Msg("Received SIGHUP, reloading configuration.")
+			case <-ticks:
+			}
+			reload()
+		}
+	}()
+}
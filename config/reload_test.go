@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadConfigFile(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	Config = Specification{
+		ConfigFile:                             configFile,
+		ApplicationFilter:                      []string{"1"},
+		DiscoveryAttributesExcludesHealthRules: []string{"appdynamics.health-rule.description"},
+		ApplicationAccountGuids:                []string{"GUID-1"},
+	}
+	t.Cleanup(func() {
+		Config = Specification{}
+		reloaded.Store(nil)
+		configFileData = nil
+	})
+
+	require.NoError(t, os.WriteFile(configFile, []byte(`{"applicationFilter":["2","3"],"controllerApplicationFilters":{"onprem":["4"]}}`), 0o600))
+	require.NoError(t, ReloadConfigFile())
+	require.Equal(t, []string{"2", "3"}, GetApplicationFilter(DefaultControllerName, Config.ApplicationFilter))
+	require.Equal(t, []string{"4"}, GetApplicationFilter("onprem", nil))
+	require.Equal(t, []string{"5"}, GetApplicationFilter("saas", []string{"5"}))
+	require.Equal(t, []string{"appdynamics.health-rule.description"}, GetDiscoveryAttributesExcludesHealthRules())
+	require.Equal(t, []string{"GUID-1"}, GetApplicationSelection(DefaultControllerName, ApplicationSelection{AccountGuids: Config.ApplicationAccountGuids}).AccountGuids)
+
+	require.NoError(t, os.WriteFile(configFile, []byte(`{"applicationNameExcludes":["*-test"],"controllerApplicationSelections":{"onprem":{"applicationAccountGuids":["GUID-2"]}}}`), 0o600))
+	require.NoError(t, ReloadConfigFile())
+	selection := GetApplicationSelection(DefaultControllerName, ApplicationSelection{AccountGuids: Config.ApplicationAccountGuids})
+	require.Equal(t, []string{"GUID-1"}, selection.AccountGuids)
+	require.True(t, selection.NameExcludes.MatchesAny("checkout-test"))
+	onprem := GetApplicationSelection("onprem", ApplicationSelection{AccountGuids: []string{"GUID-3"}})
+	require.Equal(t, []string{"GUID-2"}, onprem.AccountGuids)
+	require.Empty(t, onprem.NameExcludes)
+
+	require.NoError(t, os.WriteFile(configFile, []byte(`{"discoveryAttributesExcludesHealthRules":[]}`), 0o600))
+	require.NoError(t, ReloadConfigFile())
+	require.Equal(t, []string{"1"}, GetApplicationFilter(DefaultControllerName, Config.ApplicationFilter))
+	require.Empty(t, GetDiscoveryAttributesExcludesHealthRules())
+
+	require.NoError(t, os.WriteFile(configFile, []byte(`{`), 0o600))
+	require.Error(t, ReloadConfigFile())
+	require.Empty(t, GetDiscoveryAttributesExcludesHealthRules(), "keeps the last valid settings")
+}
+
+func TestReadSecret(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("rotated\n"), 0o600))
+
+	secret, err := ReadSecret("initial", secretFile)
+	require.NoError(t, err)
+	require.Equal(t, "rotated", secret)
+
+	secret, err = ReadSecret("initial", "")
+	require.NoError(t, err)
+	require.Equal(t, "initial", secret)
+
+	_, err = ReadSecret("", filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+}
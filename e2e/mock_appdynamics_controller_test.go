@@ -11,7 +11,7 @@ import (
 	"fmt"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
-	"github.com/steadybit/extension-appdynamics/extappdynamics"
+	"github.com/steadybit/extension-appdynamics/appdclient"
 	"github.com/steadybit/extension-kit/exthttp"
 	"net"
 	"net/http"
@@ -48,43 +48,43 @@ func handler[T any](getter func() T) http.Handler {
 	return exthttp.PanicRecovery(exthttp.LogRequestWithDefaultLogLevel(exthttp.GetterAsHandler(getter), zerolog.DebugLevel))
 }
 
-func (m *mockServer) getToken() extappdynamics.TokenResponse {
-	return extappdynamics.TokenResponse{
+func (m *mockServer) getToken() appdclient.TokenResponse {
+	return appdclient.TokenResponse{
 		AccessToken: "eyJraWQiOiJhOGU4OTBlNS0zMjMwLTRlNGEtYTU2YS04NTg4Yjk0YjlhNTIiLCJhbGciOiJIUzI1NiJ9.eyJpc3MiOiJBcHBEeW5hbWljcyIsImF1ZCI6IkFwcERfQVBJcyIsImp0aSI6IktkS3hNd3UyUS1oZzNoNWtCeFZSOFEiLCJzdWIiOiJ0ZXN0IiwiaWRUeXBlIjoiQVBJX0NMSUVOVCIsImlkIjoiYzYzNDQwZjQtODY5YS00YmRkLWJkZWEtZTJkMzlkZDc2ZmIxIiwiYWNjdElkIjoiYThlODkwZTUtMzIzMC00ZTRhLWE1NmEtODU4OGI5NGI5YTUyIiwidG50SWQiOiJhOGU4OTBlNS0zMjMwLTRlNGEtYTU2YS04NTg4Yjk0YjlhNTIiLCJhY2N0TmFtZSI6InR3aW4yMDI1MDYyMzIxNDY1MzUiLCJ0ZW5hbnROYW1lIjoidHdpbjIwMjUwNjIzMjE0NjUzNSIsImZtbVRudElkIjpudWxsLCJhY2N0UGVybSI6W10sInJvbGVJZHMiOltdLCJpYXQiOjE3NTA5MzY5NzAsIm5iZiI6MTc1MDkzNjg1MCwiZXhwIjoxNzUwOTU0OTcwLCJ0b2tlblR5cGUiOiJBQ0NFU1MifQ._lEFk3Wmuo7ch_3MqcBaQj2cDjrs-8r8nBOxMXZMkhE",
 		ExpiresIn:   18000,
 	}
 }
 
-func (m *mockServer) viewApplications() []extappdynamics.Application {
+func (m *mockServer) viewApplications() []appdclient.Application {
 	if m.state == "STATUS-500" {
 		panic("status 500")
 	}
-	return []extappdynamics.Application{{ID: 1, Name: "test", Description: "test", AccountGUID: "test"}, {ID: 2, Name: "test2", Description: "test", AccountGUID: "test"}}
+	return []appdclient.Application{{ID: 1, Name: "test", Description: "test", AccountGUID: "test"}, {ID: 2, Name: "test2", Description: "test", AccountGUID: "test"}}
 }
 
-func (m *mockServer) viewHealthRules() []extappdynamics.HealthRule {
+func (m *mockServer) viewHealthRules() []appdclient.HealthRule {
 	if m.state == "STATUS-500" {
 		panic("status 500")
 	}
-	return []extappdynamics.HealthRule{{
+	return []appdclient.HealthRule{{
 		ID: 1, Name: "Health", AffectedEntityType: "Node", Enabled: true,
 	}}
 }
 
-func (m *mockServer) viewHealthRulesForApp2() []extappdynamics.HealthRule {
+func (m *mockServer) viewHealthRulesForApp2() []appdclient.HealthRule {
 	if m.state == "STATUS-500" {
 		panic("status 500")
 	}
-	return []extappdynamics.HealthRule{{
+	return []appdclient.HealthRule{{
 		ID: 2, Name: "CPU", AffectedEntityType: "Node", Enabled: true,
 	}}
 }
 
-func (m *mockServer) viewHealthRuleViolationsForApp1() []extappdynamics.Violation {
+func (m *mockServer) viewHealthRuleViolationsForApp1() []appdclient.Violation {
 	if m.state == "STATUS-500" {
 		panic("status 500")
 	}
-	return []extappdynamics.Violation{
+	return []appdclient.Violation{
 		{
 			ID:          int64(32422),
 			Name:        "health rule name",
@@ -93,9 +93,9 @@ func (m *mockServer) viewHealthRuleViolationsForApp1() []extappdynamics.Violatio
 	}
 }
 
-func (m *mockServer) viewHealthRuleViolationsForApp2() []extappdynamics.Violation {
+func (m *mockServer) viewHealthRuleViolationsForApp2() []appdclient.Violation {
 	if m.state == "STATUS-500" {
 		panic("status 500")
 	}
-	return []extappdynamics.Violation{}
+	return []appdclient.Violation{}
 }
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+const actionStoreFile = "actions.json"
+
+// StoredAction is what a mutating action changed in a controller, persisted so that it can still be reverted after
+// the extension restarted.
+type StoredAction struct {
+	Key           string    `json:"key"`
+	Action        string    `json:"action"`
+	Controller    string    `json:"controller"`
+	ApplicationId string    `json:"applicationId"`
+	End           time.Time `json:"end"`
+	ExperimentUri *string   `json:"experimentUri,omitempty"`
+	ExecutionUri  *string   `json:"executionUri,omitempty"`
+	// ActionSuppressionName is stored before the action suppression is created, so it can be looked up by its name if
+	// the extension restarted before the ActionSuppressionId was known.
+	ActionSuppressionName string  `json:"actionSuppressionName,omitempty"`
+	ActionSuppressionId   *string `json:"actionSuppressionId,omitempty"`
+}
+
+// ActionStore persists the StoredActions as JSON file in a directory, e.g. a mounted volume. A nil store persists
+// nothing.
+type ActionStore struct {
+	path string
+
+	mutex   sync.Mutex
+	actions map[string]StoredAction
+}
+
+// NewActionStore loads the actions stored in the directory. It returns a nil store if no directory is given.
+func NewActionStore(dir string) (*ActionStore, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create action state directory: %w", err)
+	}
+
+	store := &ActionStore{path: filepath.Join(dir, actionStoreFile), actions: make(map[string]StoredAction)}
+	data, err := os.ReadFile(store.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read action state: %w", err)
+	}
+	if err := json.Unmarshal(data, &store.actions); err != nil {
+		return nil, fmt.Errorf("failed to parse action state: %w", err)
+	}
+	return store, nil
+}
+
+func (s *ActionStore) Get(key string) (StoredAction, bool) {
+	if s == nil {
+		return StoredAction{}, false
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	action, ok := s.actions[key]
+	return action, ok
+}
+
+// List returns all stored actions ordered by their key.
+func (s *ActionStore) List() []StoredAction {
+	if s == nil {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	actions := make([]StoredAction, 0, len(s.actions))
+	for _, key := range slices.Sorted(maps.Keys(s.actions)) {
+		actions = append(actions, s.actions[key])
+	}
+	return actions
+}
+
+func (s *ActionStore) Put(action StoredAction) error {
+	if s == nil {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.actions[action.Key] = action
+	return s.write()
+}
+
+func (s *ActionStore) Delete(key string) error {
+	if s == nil {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.actions[key]; !ok {
+		return nil
+	}
+	delete(s.actions, key)
+	return s.write()
+}
+
+// write replaces the file atomically, so a restart while writing never leaves a partially written file behind.
+func (s *ActionStore) write() error {
+	data, err := json.Marshal(s.actions)
+	if err != nil {
+		return fmt.Errorf("failed to encode action state: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write action state: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write action state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionStoreSurvivesRestart(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "state")
+	store, err := NewActionStore(dir)
+	require.NoError(t, err)
+
+	end := time.Now().Add(time.Minute).Truncate(time.Second)
+	require.NoError(t, store.Put(StoredAction{Key: "b", Action: "action-suppression", ApplicationId: "42", End: end, ActionSuppressionId: new("7")}))
+	require.NoError(t, store.Put(StoredAction{Key: "a", Action: "action-suppression", ApplicationId: "43", End: end}))
+
+	restarted, err := NewActionStore(dir)
+	require.NoError(t, err)
+	actions := restarted.List()
+	require.Len(t, actions, 2)
+	assert.Equal(t, "a", actions[0].Key)
+	assert.Equal(t, "7", *actions[1].ActionSuppressionId)
+	assert.True(t, end.Equal(actions[1].End))
+
+	require.NoError(t, restarted.Delete("b"))
+	require.NoError(t, restarted.Delete("unknown"))
+	restarted, err = NewActionStore(dir)
+	require.NoError(t, err)
+	_, ok := restarted.Get("b")
+	assert.False(t, ok)
+	_, ok = restarted.Get("a")
+	assert.True(t, ok)
+}
+
+func TestActionStoreRejectsCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, actionStoreFile), []byte("{"), 0o600))
+
+	_, err := NewActionStore(dir)
+	require.ErrorContains(t, err, "failed to parse action state")
+}
+
+func TestNilActionStorePersistsNothing(t *testing.T) {
+	store, err := NewActionStore("")
+	require.NoError(t, err)
+	require.Nil(t, store)
+
+	require.NoError(t, store.Put(StoredAction{Key: "a"}))
+	_, ok := store.Get("a")
+	assert.False(t, ok)
+	assert.Empty(t, store.List())
+	require.NoError(t, store.Delete("a"))
+}
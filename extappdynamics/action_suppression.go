@@ -7,22 +7,28 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/go-resty/resty/v2"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 	"github.com/steadybit/action-kit/go/action_kit_api/v2"
 	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-appdynamics/appdclient"
 	"github.com/steadybit/extension-appdynamics/config"
 	extension_kit "github.com/steadybit/extension-kit"
 	"github.com/steadybit/extension-kit/extbuild"
 	"github.com/steadybit/extension-kit/extutil"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
-type ActionSuppressionAction struct{}
+type ActionSuppressionAction struct {
+	controllers Controllers
+	store       *ActionStore
+}
 
 // Make sure action implements all required interfaces
 var (
@@ -32,15 +38,38 @@ var (
 
 type ActionSuppressionState struct {
 	ApplicationId         string
+	Controller            string
 	End                   time.Time
 	DisableAgentReporting bool
+	ActionSuppressionName string
 	ActionSuppressionId   *string
 	ExperimentUri         *string
 	ExecutionUri          *string
+	DryRun                bool
+	Trace                 ExecutionTrace
+}
+
+func (s *ActionSuppressionState) spanAttributes() []attribute.KeyValue {
+	attributes := []attribute.KeyValue{
+		attribute.String("appdynamics.controller", s.Controller),
+		attribute.String("appdynamics.application.id", s.ApplicationId),
+		attribute.Bool("appdynamics.dry-run", s.DryRun),
+	}
+	if s.ActionSuppressionId != nil {
+		attributes = append(attributes, attribute.String("appdynamics.action-suppression.id", *s.ActionSuppressionId))
+	}
+	return attributes
+}
+
+// storeKey identifies the action suppression of the state in the ActionStore.
+func (s *ActionSuppressionState) storeKey() string {
+	return s.Trace.StepId + "/" + s.Controller + "/" + s.ApplicationId
 }
 
-func NewActionSuppressionAction() action_kit_sdk.Action[ActionSuppressionState] {
-	return &ActionSuppressionAction{}
+// NewActionSuppressionAction creates the action. The created action suppressions are persisted to the store, if any,
+// so that they can still be deleted after the extension restarted.
+func NewActionSuppressionAction(controllers Controllers, store *ActionStore) action_kit_sdk.Action[ActionSuppressionState] {
+	return &ActionSuppressionAction{controllers: controllers, store: store}
 }
 func (m *ActionSuppressionAction) NewEmptyState() ActionSuppressionState {
 	return ActionSuppressionState{}
@@ -85,12 +114,26 @@ func (m *ActionSuppressionAction) Describe() action_kit_api.ActionDescription {
 				Order:        new(2),
 				Required:     new(true),
 			},
+			{
+				Name:         "dryRun",
+				Label:        "Dry run",
+				Description:  new("Only validate the permissions on the action suppressions of the application and show the action suppression that would be created, without creating it. The permissions to create and delete are validated with an action suppression that starts and ends at the same time. Always enabled if the extension runs in dry-run mode."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("false"),
+				Advanced:     new(true),
+				Order:        new(3),
+				Required:     new(false),
+			},
 		},
 		Stop: new(action_kit_api.MutatingEndpointReference{}),
 	}
 }
 
-func (m *ActionSuppressionAction) Prepare(_ context.Context, state *ActionSuppressionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+func (m *ActionSuppressionAction) Prepare(ctx context.Context, state *ActionSuppressionState, request action_kit_api.PrepareActionRequestBody) (_ *action_kit_api.PrepareResult, err error) {
+	state.Trace = newExecutionTrace(request)
+	_, span := startActionSpan(ctx, "action-suppression.prepare", state.Trace)
+	defer func() { endActionSpan(span, nil, err) }()
+
 	applicationID := request.Target.Attributes["appdynamics.application.id"]
 	if len(applicationID) == 0 {
 		return nil, extension_kit.ToError("Target is missing the 'appdynamics.application.id' tag.", nil)
@@ -99,37 +142,61 @@ func (m *ActionSuppressionAction) Prepare(_ context.Context, state *ActionSuppre
 	duration := request.Config["duration"].(float64)
 	end := time.Now().Add(time.Millisecond * time.Duration(duration))
 
+	controller, err := m.controllers.get(getControllerName(request.Target.Attributes, AppAttribute+AppController))
+	if err != nil {
+		return nil, extension_kit.ToError("Failed to resolve the AppDynamics controller of the target.", err)
+	}
+
 	state.ApplicationId = extutil.ToString(applicationID[0])
+	state.Controller = controller.Name
 	state.End = end
 	state.DisableAgentReporting = request.Config["disableAgentReporting"].(bool)
+	state.DryRun = isDryRun(request.Config)
+	if request.ExecutionContext != nil {
+		state.ExperimentUri = request.ExecutionContext.ExperimentUri
+		state.ExecutionUri = request.ExecutionContext.ExecutionUri
+	}
+	span.SetAttributes(state.spanAttributes()...)
 
 	return nil, nil
 }
 
-func (m *ActionSuppressionAction) Start(ctx context.Context, state *ActionSuppressionState) (*action_kit_api.StartResult, error) {
-	return ActionSuppressionStart(ctx, state, RestyClient)
+func (m *ActionSuppressionAction) Start(ctx context.Context, state *ActionSuppressionState) (_ *action_kit_api.StartResult, err error) {
+	ctx, span := startActionSpan(ctx, "action-suppression.start", state.Trace, state.spanAttributes()...)
+	defer func() {
+		span.SetAttributes(state.spanAttributes()...)
+		endActionSpan(span, nil, err)
+	}()
+	ctx = appdclient.WithAuditInitiator(ctx, state.Trace.auditInitiator())
+
+	controller, err := m.controllers.get(state.Controller)
+	if err != nil {
+		return nil, extension_kit.ToError("Failed to resolve the AppDynamics controller of the target.", err)
+	}
+	return ActionSuppressionStart(ctx, state, controller.Client, m.store)
 }
 
-func (m *ActionSuppressionAction) Stop(ctx context.Context, state *ActionSuppressionState) (*action_kit_api.StopResult, error) {
-	return ActionSuppressionStop(ctx, state, RestyClient)
+func (m *ActionSuppressionAction) Stop(ctx context.Context, state *ActionSuppressionState) (_ *action_kit_api.StopResult, err error) {
+	ctx, span := startActionSpan(ctx, "action-suppression.stop", state.Trace, state.spanAttributes()...)
+	defer func() { endActionSpan(span, nil, err) }()
+	ctx = appdclient.WithAuditInitiator(ctx, state.Trace.auditInitiator())
+
+	controller, err := m.controllers.get(state.Controller)
+	if err != nil {
+		return nil, extension_kit.ToError("Failed to resolve the AppDynamics controller of the target.", err)
+	}
+	return ActionSuppressionStop(ctx, state, controller.Client, m.store)
 }
 
-func ActionSuppressionStart(ctx context.Context, state *ActionSuppressionState, client *resty.Client) (*action_kit_api.StartResult, error) {
-	// Get configured Time Zone if none is defined
-	var timezone string
-	if config.Config.ActionSuppressionTimezone == "" {
-		tz, err := GetLocalTimezone()
-		if err != nil {
-			return nil, new(extension_kit.ToError("Failed to get current timezone.", err))
-		}
-		timezone = tz
-	} else {
-		timezone = config.Config.ActionSuppressionTimezone
+func ActionSuppressionStart(ctx context.Context, state *ActionSuppressionState, client AppDynamicsClient, store *ActionStore) (*action_kit_api.StartResult, error) {
+	timezone, err := getActionSuppressionTimezone()
+	if err != nil {
+		return nil, new(extension_kit.ToError("Failed to get current timezone.", err))
 	}
 
-	actionSuppressionRequest := ActionSuppressionRequest{
+	actionSuppressionRequest := appdclient.ActionSuppressionRequest{
 		Name:                    "Steadybit-" + state.ApplicationId + "-" + uuid.New().String(),
-		Affects:                 Affects{AffectedInfoType: "APPLICATION"},
+		Affects:                 appdclient.Affects{AffectedInfoType: "APPLICATION"},
 		DisableAgentReporting:   state.DisableAgentReporting,
 		StartTime:               time.Now().Format(time.RFC3339),
 		EndTime:                 state.End.Format(time.RFC3339),
@@ -137,24 +204,41 @@ func ActionSuppressionStart(ctx context.Context, state *ActionSuppressionState,
 		Timezone:                timezone,
 	}
 
-	var actionSuppressionResponse ActionSuppressionResponse
-	res, err := client.R().
-		SetContext(ctx).
-		SetBody(actionSuppressionRequest).
-		SetResult(&actionSuppressionResponse).
-		Post("/controller/alerting/rest/v1/applications/" + state.ApplicationId + "/action-suppressions")
+	if state.DryRun {
+		return actionSuppressionDryRun(ctx, state, client, actionSuppressionRequest)
+	}
 
-	// resty returns a nil/empty response when err != nil, so res.String() is only safe to
-	// read on the !IsSuccess() path below where err is nil and res is guaranteed non-nil.
-	if err != nil {
-		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to create action suppression in AppDynamics for Application ID %s.", state.ApplicationId), err))
+	// The action suppression is stored before it is created, so that it can be found by its name even if the extension
+	// restarts before the response was received.
+	state.ActionSuppressionName = actionSuppressionRequest.Name
+	storedAction := StoredAction{
+		Key:                   state.storeKey(),
+		Action:                config.ActionActionSuppression,
+		Controller:            state.Controller,
+		ApplicationId:         state.ApplicationId,
+		End:                   state.End,
+		ExperimentUri:         state.ExperimentUri,
+		ExecutionUri:          state.ExecutionUri,
+		ActionSuppressionName: actionSuppressionRequest.Name,
+	}
+	if err := store.Put(storedAction); err != nil {
+		return nil, new(extension_kit.ToError("Failed to persist the action suppression before creating it.", err))
 	}
 
-	if !res.IsSuccess() {
-		return nil, new(extension_kit.ToError(fmt.Sprintf("AppDynamics API responded with unexpected status code %d while creating action suppression for Application ID %s. Full response: %v", res.StatusCode(), state.ApplicationId, res.String()), nil))
+	actionSuppressionResponse, err := client.CreateActionSuppression(ctx, state.ApplicationId, actionSuppressionRequest)
+	if err != nil {
+		if err := store.Delete(storedAction.Key); err != nil {
+			log.Warn().Err(err).Msgf("Failed to remove the action suppression for Application ID %s from the action state.", state.ApplicationId)
+		}
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to create action suppression in AppDynamics for Application ID %s.", state.ApplicationId), err))
 	}
 
 	state.ActionSuppressionId = new(strconv.Itoa(actionSuppressionResponse.ID))
+	countCreatedActionSuppression(storedAction.Key)
+	storedAction.ActionSuppressionId = state.ActionSuppressionId
+	if err := store.Put(storedAction); err != nil {
+		log.Warn().Err(err).Msgf("Failed to persist action suppression %s for Application ID %s.", *state.ActionSuppressionId, state.ApplicationId)
+	}
 
 	return &action_kit_api.StartResult{
 		Messages: &action_kit_api.Messages{
@@ -163,21 +247,74 @@ func ActionSuppressionStart(ctx context.Context, state *ActionSuppressionState,
 	}, nil
 }
 
-func ActionSuppressionStop(ctx context.Context, state *ActionSuppressionState, client *resty.Client) (*action_kit_api.StopResult, error) {
-	if state.ActionSuppressionId == nil {
-		return nil, nil
-	}
+// actionSuppressionDryRunArtifact is the artifact of a dry run: the action suppression that would have been created and
+// the outcome of the permission probes.
+type actionSuppressionDryRunArtifact struct {
+	Request     appdclient.ActionSuppressionRequest `json:"request"`
+	Permissions []PermissionCheckResult             `json:"permissions"`
+}
 
-	res, err := client.R().
-		SetContext(ctx).
-		Delete("/controller/alerting/rest/v1/applications/" + state.ApplicationId + "/action-suppressions/" + *state.ActionSuppressionId)
+// actionSuppressionDryRun validates the permissions the action needs and returns the action suppression that would
+// have been created as artifact. Like the permission check, it creates and deletes an action suppression that starts
+// and ends at the same time to validate the permissions to create and delete action suppressions.
+func actionSuppressionDryRun(ctx context.Context, state *ActionSuppressionState, client AppDynamicsClient, actionSuppressionRequest appdclient.ActionSuppressionRequest) (*action_kit_api.StartResult, error) {
+	if _, err := client.ListActionSuppressions(ctx, state.ApplicationId); err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Dry run failed to access the action suppressions in AppDynamics for Application ID %s.", state.ApplicationId), err))
+	}
+	permissions := checkActionSuppressionPermissions(ctx, state.ApplicationId, client)
 
+	artifact, err := newJsonArtifact("action-suppression-dry-run.json", actionSuppressionDryRunArtifact{
+		Request:     actionSuppressionRequest,
+		Permissions: permissions,
+	})
 	if err != nil {
-		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to delete action suppression in AppDynamics for Application ID %s.", state.ApplicationId), err))
+		return nil, new(extension_kit.ToError("Failed to encode the action suppression.", err))
+	}
+
+	result := &action_kit_api.StartResult{
+		Messages: &action_kit_api.Messages{
+			action_kit_api.Message{Level: extutil.Ptr(action_kit_api.Info), Message: fmt.Sprintf("Dry run: Action Suppression '%s' would be created from %s to %s. (application ID %s)", actionSuppressionRequest.Name, actionSuppressionRequest.StartTime, actionSuppressionRequest.EndTime, state.ApplicationId)},
+		},
+		Artifacts: &action_kit_api.Artifacts{artifact},
+	}
+	if missing := getMissingPermissions(permissions); len(missing) > 0 {
+		result.Error = new(action_kit_api.ActionKitError{
+			Title:  fmt.Sprintf("Dry run: the API client lacks permissions for Application ID %s: %s.", state.ApplicationId, strings.Join(missing, ", ")),
+			Status: extutil.Ptr(action_kit_api.Failed),
+		})
+	}
+	return result, nil
+}
+
+func ActionSuppressionStop(ctx context.Context, state *ActionSuppressionState, client AppDynamicsClient, store *ActionStore) (*action_kit_api.StopResult, error) {
+	if state.ActionSuppressionId == nil {
+		// The extension may have restarted before the start of the action returned the id of the action suppression.
+		storedAction, ok := store.Get(state.storeKey())
+		if !ok {
+			return nil, nil
+		}
+		actionSuppressionId, err := getStoredActionSuppressionId(ctx, storedAction, client)
+		if err != nil {
+			return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to look up the action suppression in AppDynamics for Application ID %s.", state.ApplicationId), err))
+		}
+		if actionSuppressionId == nil {
+			return nil, deleteStoredAction(store, storedAction.Key)
+		}
+		state.ActionSuppressionId = actionSuppressionId
 	}
 
-	if !res.IsSuccess() {
-		log.Err(err).Msgf("AppDynamics API responded with unexpected status code %d while deleting action suppression for Application ID %s. Full response: %v", res.StatusCode(), state.ApplicationId, res.String())
+	// The action suppression is only forgotten once it is confirmed to be gone, otherwise it is kept in the store, so
+	// that it is deleted by DeleteExpiredActionSuppressions at the latest.
+	err := client.DeleteActionSuppression(ctx, state.ApplicationId, *state.ActionSuppressionId)
+	var statusErr *appdclient.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		log.Debug().Msgf("Action suppression %s for Application ID %s was already deleted.", *state.ActionSuppressionId, state.ApplicationId)
+	} else if err != nil {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to delete action suppression in AppDynamics for Application ID %s.", state.ApplicationId), err))
+	}
+	countDeletedActionSuppression(state.storeKey())
+	if err := deleteStoredAction(store, state.storeKey()); err != nil {
+		return nil, err
 	}
 
 	return &action_kit_api.StopResult{
@@ -187,6 +324,107 @@ func ActionSuppressionStop(ctx context.Context, state *ActionSuppressionState, c
 	}, nil
 }
 
+// getStoredActionSuppressionId returns the id of a stored action suppression. If the id wasn't stored, the action
+// suppression is looked up by its name. It returns nil if the action suppression doesn't exist.
+func getStoredActionSuppressionId(ctx context.Context, storedAction StoredAction, client AppDynamicsClient) (*string, error) {
+	if storedAction.ActionSuppressionId != nil {
+		return storedAction.ActionSuppressionId, nil
+	}
+	actionSuppressions, err := client.ListActionSuppressions(ctx, storedAction.ApplicationId)
+	if err != nil {
+		return nil, err
+	}
+	for _, actionSuppression := range actionSuppressions {
+		if actionSuppression.Name == storedAction.ActionSuppressionName {
+			return new(strconv.Itoa(actionSuppression.ID)), nil
+		}
+	}
+	return nil, nil
+}
+
+func deleteStoredAction(store *ActionStore, key string) error {
+	if err := store.Delete(key); err != nil {
+		return new(extension_kit.ToError("Failed to remove the action suppression from the action state.", err))
+	}
+	return nil
+}
+
+// expiredActionSuppressionsInterval is the interval in which expired action suppressions are deleted.
+const expiredActionSuppressionsInterval = time.Minute
+
+// StartDeletingExpiredActionSuppressions deletes the expired action suppressions in the background, right away and then
+// every minute, so action suppressions of steps that are never stopped don't pile up while the extension runs.
+func StartDeletingExpiredActionSuppressions(controllers Controllers, store *ActionStore) {
+	go func() {
+		DeleteExpiredActionSuppressions(context.Background(), controllers, store)
+		for range time.Tick(expiredActionSuppressionsInterval) {
+			DeleteExpiredActionSuppressions(context.Background(), controllers, store)
+		}
+	}()
+}
+
+// DeleteExpiredActionSuppressions deletes the stored action suppressions whose end has passed. They are left behind if
+// the extension restarted during a step or the step was never stopped.
+func DeleteExpiredActionSuppressions(ctx context.Context, controllers Controllers, store *ActionStore) {
+	for _, storedAction := range store.List() {
+		if storedAction.Action != config.ActionActionSuppression || storedAction.End.After(time.Now()) {
+			continue
+		}
+		controller, err := controllers.get(storedAction.Controller)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Failed to delete the stored action suppression for Application ID %s.", storedAction.ApplicationId)
+			continue
+		}
+		actionSuppressionId, err := getStoredActionSuppressionId(ctx, storedAction, controller.Client)
+		if err == nil && actionSuppressionId != nil {
+			err = controller.Client.DeleteActionSuppression(ctx, storedAction.ApplicationId, *actionSuppressionId)
+			var statusErr *appdclient.StatusError
+			if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+				err = nil
+			}
+		}
+		if err != nil {
+			log.Warn().Err(err).Msgf("Failed to delete the stored action suppression for Application ID %s.", storedAction.ApplicationId)
+			continue
+		}
+		if actionSuppressionId != nil {
+			countDeletedActionSuppression(storedAction.Key)
+			log.Info().Msgf("Deleted the expired action suppression '%s' for Application ID %s.", storedAction.ActionSuppressionName, storedAction.ApplicationId)
+		}
+		if err := store.Delete(storedAction.Key); err != nil {
+			log.Warn().Err(err).Msg("Failed to remove the action suppression from the action state.")
+		}
+	}
+}
+
+// countedActionSuppressions holds the store keys of the action suppressions counted by activeActionSuppressions.
+var countedActionSuppressions = sync.Map{}
+
+// countCreatedActionSuppression increments activeActionSuppressions and remembers the action suppression, so that only
+// the action suppressions counted by this process decrement the gauge again. Action suppressions created before a
+// restart are deleted without being counted.
+func countCreatedActionSuppression(key string) {
+	if _, counted := countedActionSuppressions.LoadOrStore(key, struct{}{}); !counted {
+		activeActionSuppressions.Inc()
+	}
+}
+
+// countDeletedActionSuppression decrements activeActionSuppressions once the action suppression is confirmed to be gone.
+func countDeletedActionSuppression(key string) {
+	if _, counted := countedActionSuppressions.LoadAndDelete(key); counted {
+		activeActionSuppressions.Dec()
+	}
+}
+
+// getActionSuppressionTimezone returns the configured timezone of action suppressions or the local one if none is
+// configured.
+func getActionSuppressionTimezone() (string, error) {
+	if config.Config.ActionSuppressionTimezone != "" {
+		return config.Config.ActionSuppressionTimezone, nil
+	}
+	return GetLocalTimezone()
+}
+
 func GetLocalTimezone() (string, error) {
 	if tz := os.Getenv("TZ"); tz != "" && strings.Contains(tz, "/") {
 		return tz, nil
@@ -2,6 +2,7 @@ package extappdynamics
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -10,11 +11,14 @@ import (
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	actionapitest "github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/steadybit/extension-appdynamics/config"
 )
 
 func TestPrepareSuccess(t *testing.T) {
-	a := &ActionSuppressionAction{}
+	a := &ActionSuppressionAction{controllers: Controllers{{Name: "default"}}}
 	state := a.NewEmptyState()
 	req := actionapitest.PrepareActionRequestBody{
 		Target: &actionapitest.Target{Attributes: map[string][]string{
@@ -70,7 +74,7 @@ func TestPrepareMissingApplicationID(t *testing.T) {
 
 func TestActionSuppressionStartSuccess(t *testing.T) {
 	// Setup a fake server
-	var receivedReq ActionSuppressionRequest
+	var receivedReq appdclient.ActionSuppressionRequest
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			t.Errorf("expected POST, got %s", r.Method)
@@ -86,18 +90,14 @@ func TestActionSuppressionStartSuccess(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	client := resty.New().SetBaseURL(ts.URL)
-	old := RestyClient
-	RestyClient = client
-	defer func() { RestyClient = old }()
-
+	client := appdclient.New(resty.New().SetBaseURL(ts.URL))
 	state := ActionSuppressionState{
 		ApplicationId:         "app-123",
 		DisableAgentReporting: true,
 		End:                   time.Now().Add(5 * time.Second),
 	}
 
-	res, err := ActionSuppressionStart(context.Background(), &state, RestyClient)
+	res, err := ActionSuppressionStart(context.Background(), &state, client, nil)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -122,7 +122,7 @@ func TestActionSuppressionStartSuccess(t *testing.T) {
 }
 
 func TestActionSuppressionStopNoID(t *testing.T) {
-	res, err := ActionSuppressionStop(context.Background(), &ActionSuppressionState{}, RestyClient)
+	res, err := ActionSuppressionStop(context.Background(), &ActionSuppressionState{}, nil, nil)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -143,17 +143,13 @@ func TestActionSuppressionStopSuccess(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	client := resty.New().SetBaseURL(ts.URL)
-	old := RestyClient
-	RestyClient = client
-	defer func() { RestyClient = old }()
-
+	client := appdclient.New(resty.New().SetBaseURL(ts.URL))
 	state := ActionSuppressionState{
 		ApplicationId:       "app-123",
 		ActionSuppressionId: new("123"),
 	}
 
-	res, err := ActionSuppressionStop(context.Background(), &state, RestyClient)
+	res, err := ActionSuppressionStop(context.Background(), &state, client, nil)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -161,3 +157,358 @@ func TestActionSuppressionStopSuccess(t *testing.T) {
 		t.Fatal("expected non-nil StopResult with messages")
 	}
 }
+
+func TestPrepareRoutesToTargetController(t *testing.T) {
+	a := &ActionSuppressionAction{controllers: Controllers{{Name: "default"}, {Name: "onprem"}}}
+	state := a.NewEmptyState()
+	req := actionapitest.PrepareActionRequestBody{
+		Target: &actionapitest.Target{Attributes: map[string][]string{
+			"appdynamics.application.id":         {"app-id-123"},
+			"appdynamics.application.controller": {"onprem"},
+		}},
+		Config: map[string]any{
+			"duration":              float64(1000),
+			"disableAgentReporting": false,
+		},
+	}
+
+	if _, err := a.Prepare(context.Background(), &state, req); err != nil {
+		t.Fatalf("Prepare returned unexpected error: %v", err)
+	}
+	if state.Controller != "onprem" {
+		t.Errorf("expected Controller 'onprem', got %q", state.Controller)
+	}
+
+	req.Target.Attributes["appdynamics.application.controller"] = []string{"unknown"}
+	if _, err := a.Prepare(context.Background(), &state, req); err == nil {
+		t.Fatal("expected error for unknown controller, got nil")
+	}
+}
+
+func TestActionSuppressionStartDryRun(t *testing.T) {
+	var created appdclient.ActionSuppressionRequest
+	deleted := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/controller/alerting/rest/v1/applications/app-123/action-suppressions":
+			_, _ = w.Write([]byte(`[]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/controller/alerting/rest/v1/applications/app-123/action-suppressions":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			_, _ = w.Write([]byte(`{"id":7}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/controller/alerting/rest/v1/applications/app-123/action-suppressions/7":
+			deleted = true
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	client := appdclient.New(resty.New().SetBaseURL(ts.URL))
+	state := ActionSuppressionState{
+		ApplicationId:         "app-123",
+		DisableAgentReporting: true,
+		End:                   time.Now().Add(5 * time.Second),
+		DryRun:                true,
+	}
+
+	res, err := ActionSuppressionStart(context.Background(), &state, client, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if state.ActionSuppressionId != nil {
+		t.Errorf("expected no ActionSuppressionId in dry run, got %q", *state.ActionSuppressionId)
+	}
+	if res == nil || res.Messages == nil || res.Artifacts == nil || len(*res.Artifacts) != 1 {
+		t.Fatal("expected StartResult with messages and the request as artifact")
+	}
+	if res.Error != nil {
+		t.Errorf("expected no error with all permissions granted, got %+v", res.Error)
+	}
+	if created.StartTime == "" || created.StartTime != created.EndTime || created.DisableAgentReporting || !deleted {
+		t.Errorf("expected a zero-length action suppression to be created and deleted, got %+v (deleted: %v)", created, deleted)
+	}
+
+	data, err := base64.StdEncoding.DecodeString((*res.Artifacts)[0].Data)
+	if err != nil {
+		t.Fatalf("failed to decode artifact: %v", err)
+	}
+	var artifact actionSuppressionDryRunArtifact
+	if err := json.Unmarshal(data, &artifact); err != nil {
+		t.Fatalf("failed to unmarshal artifact: %v", err)
+	}
+	if len(artifact.Permissions) != 2 || artifact.Permissions[0].Outcome != PermissionGranted || artifact.Permissions[1].Outcome != PermissionGranted {
+		t.Errorf("expected create and delete to be granted, got %+v", artifact.Permissions)
+	}
+	payload := artifact.Request
+	if !strings.HasPrefix(payload.Name, "Steadybit-app-123") || !payload.DisableAgentReporting || payload.SuppressionScheduleType != "ONE_TIME" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+}
+
+func TestActionSuppressionStartDryRunFailsWithoutCreatePermission(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+
+	client := appdclient.New(resty.New().SetBaseURL(ts.URL))
+	state := ActionSuppressionState{ApplicationId: "app-123", End: time.Now().Add(5 * time.Second), DryRun: true}
+
+	res, err := ActionSuppressionStart(context.Background(), &state, client, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if res == nil || res.Error == nil || !strings.Contains(res.Error.Title, "Create action suppressions") {
+		t.Errorf("expected the dry run to fail for the missing create permission, got %+v", res)
+	}
+}
+
+func TestActionSuppressionStartDryRunFailsWithoutAccess(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	client := appdclient.New(resty.New().SetBaseURL(ts.URL))
+	state := ActionSuppressionState{ApplicationId: "app-123", End: time.Now().Add(5 * time.Second), DryRun: true}
+
+	if _, err := ActionSuppressionStart(context.Background(), &state, client, nil); err == nil {
+		t.Fatal("expected error if the action suppressions can't be accessed, got nil")
+	}
+}
+
+func TestPrepareDryRun(t *testing.T) {
+	a := &ActionSuppressionAction{controllers: Controllers{{Name: "default"}}}
+	req := actionapitest.PrepareActionRequestBody{
+		Target: &actionapitest.Target{Attributes: map[string][]string{
+			"appdynamics.application.id": {"app-id-123"},
+		}},
+		Config: map[string]any{
+			"duration":              float64(1000),
+			"disableAgentReporting": false,
+		},
+	}
+
+	state := a.NewEmptyState()
+	if _, err := a.Prepare(context.Background(), &state, req); err != nil {
+		t.Fatalf("Prepare returned unexpected error: %v", err)
+	}
+	if state.DryRun {
+		t.Error("expected no dry run by default")
+	}
+
+	req.Config["dryRun"] = true
+	state = a.NewEmptyState()
+	if _, err := a.Prepare(context.Background(), &state, req); err != nil {
+		t.Fatalf("Prepare returned unexpected error: %v", err)
+	}
+	if !state.DryRun {
+		t.Error("expected dry run if enabled for the action")
+	}
+
+	config.Config.DryRun = true
+	t.Cleanup(func() { config.Config.DryRun = false })
+	req.Config["dryRun"] = false
+	state = a.NewEmptyState()
+	if _, err := a.Prepare(context.Background(), &state, req); err != nil {
+		t.Fatalf("Prepare returned unexpected error: %v", err)
+	}
+	if !state.DryRun {
+		t.Error("expected dry run if enabled for the extension")
+	}
+}
+
+func TestActionSuppressionStopAfterRestart(t *testing.T) {
+	var deleted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[{"id":99,"name":"Steadybit-other"},{"id":123,"name":"Steadybit-app-123-lost"}]`))
+		case http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	store, err := NewActionStore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	state := ActionSuppressionState{
+		ApplicationId: "app-123",
+		Controller:    "default",
+		End:           time.Now().Add(5 * time.Second),
+		Trace:         ExecutionTrace{StepId: "step-1"},
+	}
+	// The extension restarted after the action suppression was stored, but before its id was known.
+	storedAction := StoredAction{Key: state.storeKey(), Action: config.ActionActionSuppression, Controller: "default", ApplicationId: "app-123", End: state.End, ActionSuppressionName: "Steadybit-app-123-lost"}
+	if err := store.Put(storedAction); err != nil {
+		t.Fatalf("failed to store action: %v", err)
+	}
+
+	restarted, err := NewActionStore(dir)
+	if err != nil {
+		t.Fatalf("failed to load store: %v", err)
+	}
+	client := appdclient.New(resty.New().SetBaseURL(ts.URL))
+	if _, err := ActionSuppressionStop(context.Background(), &state, client, restarted); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(deleted) != 1 || deleted[0] != "/controller/alerting/rest/v1/applications/app-123/action-suppressions/123" {
+		t.Errorf("expected the action suppression found by name to be deleted, got %v", deleted)
+	}
+	if _, ok := restarted.Get(state.storeKey()); ok {
+		t.Error("expected the action suppression to be removed from the store")
+	}
+}
+
+func TestDeleteExpiredActionSuppressions(t *testing.T) {
+	var deleted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted = append(deleted, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	store, err := NewActionStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	for _, action := range []StoredAction{
+		{Key: "expired", Action: config.ActionActionSuppression, Controller: "default", ApplicationId: "1", End: time.Now().Add(-time.Minute), ActionSuppressionId: new("11")},
+		{Key: "running", Action: config.ActionActionSuppression, Controller: "default", ApplicationId: "2", End: time.Now().Add(time.Minute), ActionSuppressionId: new("22")},
+	} {
+		if err := store.Put(action); err != nil {
+			t.Fatalf("failed to store action: %v", err)
+		}
+	}
+
+	controllers := Controllers{{Name: "default", Client: appdclient.New(resty.New().SetBaseURL(ts.URL))}}
+	DeleteExpiredActionSuppressions(context.Background(), controllers, store)
+
+	if len(deleted) != 1 || deleted[0] != "/controller/alerting/rest/v1/applications/1/action-suppressions/11" {
+		t.Errorf("expected only the expired action suppression to be deleted, got %v", deleted)
+	}
+	if _, ok := store.Get("expired"); ok {
+		t.Error("expected the expired action suppression to be removed from the store")
+	}
+	if _, ok := store.Get("running"); !ok {
+		t.Error("expected the running action suppression to be kept")
+	}
+}
+
+func TestActionSuppressionStartPersistsActionSuppression(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"id": 123})
+	}))
+	defer ts.Close()
+
+	store, err := NewActionStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	state := ActionSuppressionState{
+		ApplicationId: "app-123",
+		Controller:    "default",
+		End:           time.Now().Add(5 * time.Second),
+		ExecutionUri:  new("exec://example"),
+		Trace:         ExecutionTrace{StepId: "step-1"},
+	}
+
+	if _, err := ActionSuppressionStart(context.Background(), &state, appdclient.New(resty.New().SetBaseURL(ts.URL)), store); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	storedAction, ok := store.Get(state.storeKey())
+	if !ok {
+		t.Fatal("expected the action suppression to be stored")
+	}
+	if storedAction.ActionSuppressionId == nil || *storedAction.ActionSuppressionId != "123" {
+		t.Errorf("expected stored ActionSuppressionId '123', got %v", storedAction.ActionSuppressionId)
+	}
+	if storedAction.ActionSuppressionName != state.ActionSuppressionName || storedAction.ExecutionUri == nil {
+		t.Errorf("unexpected stored action %+v", storedAction)
+	}
+}
+
+func TestActionSuppressionGaugeOnlyCountsConfirmedDeletes(t *testing.T) {
+	deleteStatus := http.StatusInternalServerError
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(deleteStatus)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"id": 123})
+	}))
+	defer ts.Close()
+
+	client := appdclient.New(resty.New().SetBaseURL(ts.URL))
+	before := testutil.ToFloat64(activeActionSuppressions)
+
+	// An action suppression created before a restart isn't counted, so deleting it must not decrement the gauge.
+	uncounted := ActionSuppressionState{ApplicationId: "app-123", ActionSuppressionId: new("7"), Trace: ExecutionTrace{StepId: "uncounted"}}
+	deleteStatus = http.StatusNoContent
+	_, _ = ActionSuppressionStop(context.Background(), &uncounted, client, nil)
+	if got := testutil.ToFloat64(activeActionSuppressions); got != before {
+		t.Fatalf("expected the gauge to stay at %v, got %v", before, got)
+	}
+
+	state := ActionSuppressionState{ApplicationId: "app-123", End: time.Now().Add(5 * time.Second), Trace: ExecutionTrace{StepId: "counted"}}
+	if _, err := ActionSuppressionStart(context.Background(), &state, client, nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	deleteStatus = http.StatusInternalServerError
+	if _, err := ActionSuppressionStop(context.Background(), &state, client, nil); err == nil {
+		t.Fatal("expected an error when the delete fails")
+	}
+	if got := testutil.ToFloat64(activeActionSuppressions); got != before+1 {
+		t.Fatalf("expected the gauge to stay at %v after a failed delete, got %v", before+1, got)
+	}
+
+	deleteStatus = http.StatusNotFound
+	_, _ = ActionSuppressionStop(context.Background(), &state, client, nil)
+	if got := testutil.ToFloat64(activeActionSuppressions); got != before {
+		t.Fatalf("expected the gauge to be back at %v, got %v", before, got)
+	}
+}
+
+func TestActionSuppressionStopKeepsStoredActionIfDeleteFails(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	store, err := NewActionStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	state := ActionSuppressionState{
+		ApplicationId:       "app-123",
+		Controller:          "default",
+		ActionSuppressionId: new("123"),
+		End:                 time.Now().Add(5 * time.Second),
+		Trace:               ExecutionTrace{StepId: "step-1"},
+	}
+	if err := store.Put(StoredAction{Key: state.storeKey(), Action: config.ActionActionSuppression, Controller: "default", ApplicationId: "app-123", End: state.End, ActionSuppressionId: state.ActionSuppressionId}); err != nil {
+		t.Fatalf("failed to store action: %v", err)
+	}
+
+	if _, err := ActionSuppressionStop(context.Background(), &state, appdclient.New(resty.New().SetBaseURL(ts.URL)), store); err == nil {
+		t.Fatal("expected an error when the delete is forbidden")
+	}
+	if _, ok := store.Get(state.storeKey()); !ok {
+		t.Error("expected the action suppression to be kept in the store")
+	}
+}
@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/steadybit/extension-appdynamics/config"
+)
+
+// applicationCache keeps the applications of a controller for config.Specification.ApplicationCacheTtl, so that the
+// discoveries refreshing at about the same time share a single request. The zero value is an empty cache.
+type applicationCache struct {
+	mutex        sync.Mutex
+	applications []appdclient.Application
+	fetchedAt    time.Time
+}
+
+// get returns the cached applications or fetches them if the cache expired. Concurrent callers wait for a single fetch.
+func (c *applicationCache) get(ctx context.Context, client AppDynamicsClient) ([]appdclient.Application, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ttl := config.Config.ApplicationCacheTtl
+	if ttl > 0 && c.applications != nil && time.Since(c.fetchedAt) < ttl {
+		return c.applications, nil
+	}
+	applications, err := client.ListApplications(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.applications = applications
+	c.fetchedAt = time.Now()
+	return applications, nil
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"slices"
+	"strconv"
+
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/steadybit/extension-appdynamics/config"
+)
+
+// listApplications returns the applications of the controller that pass its application filters. All discoveries list
+// the applications through it, so that they consistently report targets for the same applications and share the
+// cached response of the controller.
+func (c *Controller) listApplications(ctx context.Context) ([]appdclient.Application, error) {
+	applications, err := c.applications.get(ctx, c.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	idFilter := c.getApplicationFilter()
+	selection := c.getApplicationSelection()
+	filtered := make([]appdclient.Application, 0, len(applications))
+	for _, app := range applications {
+		if includesApplication(app, idFilter, selection) {
+			filtered = append(filtered, app)
+		}
+	}
+	return filtered, nil
+}
+
+// includesApplication reports whether the application passes all configured filters: its id is part of the id filter,
+// its account is one of the account GUIDs, its name matches any of the includes and none of the excludes.
+func includesApplication(app appdclient.Application, idFilter []string, selection config.ApplicationSelection) bool {
+	if len(idFilter) > 0 && !slices.Contains(idFilter, strconv.Itoa(app.ID)) {
+		return false
+	}
+	if len(selection.AccountGuids) > 0 && !slices.Contains(selection.AccountGuids, app.AccountGUID) {
+		return false
+	}
+	if len(selection.NameIncludes) > 0 && !selection.NameIncludes.MatchesAny(app.Name) {
+		return false
+	}
+	return !selection.NameExcludes.MatchesAny(app.Name)
+}
+
+// getApplicationFilter returns the id based application filter of the controller, which may be changed at runtime
+// through the config file.
+func (c *Controller) getApplicationFilter() []string {
+	return config.GetApplicationFilter(c.Name, c.ApplicationFilter)
+}
+
+// getApplicationSelection returns the name and account filters of the controller, which may be changed at runtime
+// through the config file.
+func (c *Controller) getApplicationSelection() config.ApplicationSelection {
+	return config.GetApplicationSelection(c.Name, config.ApplicationSelection{
+		NameIncludes: c.ApplicationNameIncludes,
+		NameExcludes: c.ApplicationNameExcludes,
+		AccountGuids: c.ApplicationAccountGuids,
+	})
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/steadybit/extension-appdynamics/config"
+	"github.com/stretchr/testify/require"
+)
+
+type applicationsClient struct {
+	AppDynamicsClient
+	applications []appdclient.Application
+}
+
+func (c *applicationsClient) ListApplications(context.Context) ([]appdclient.Application, error) {
+	return c.applications, nil
+}
+
+func mustParseNamePatterns(t *testing.T, patterns ...string) config.NamePatterns {
+	var namePatterns config.NamePatterns
+	for _, pattern := range patterns {
+		namePattern, err := config.ParseNamePattern(pattern)
+		require.NoError(t, err)
+		namePatterns = append(namePatterns, namePattern)
+	}
+	return namePatterns
+}
+
+func TestListApplications_Filters(t *testing.T) {
+	client := &applicationsClient{applications: []appdclient.Application{
+		{ID: 1, Name: "checkout-eu", AccountGUID: "GUID-1"},
+		{ID: 2, Name: "checkout-test", AccountGUID: "GUID-1"},
+		{ID: 3, Name: "checkout-us", AccountGUID: "GUID-2"},
+		{ID: 4, Name: "ledger", AccountGUID: "GUID-1"},
+	}}
+
+	tests := []struct {
+		name       string
+		controller *Controller
+		expected   []int
+	}{
+		{"no filter", &Controller{}, []int{1, 2, 3, 4}},
+		{"ids", &Controller{ApplicationFilter: []string{"2", "4"}}, []int{2, 4}},
+		{"name includes", &Controller{ApplicationNameIncludes: mustParseNamePatterns(t, "checkout-*")}, []int{1, 2, 3}},
+		{"name includes and excludes", &Controller{
+			ApplicationNameIncludes: mustParseNamePatterns(t, "checkout-*"),
+			ApplicationNameExcludes: mustParseNamePatterns(t, "/-test$/"),
+		}, []int{1, 3}},
+		{"account guids", &Controller{ApplicationAccountGuids: []string{"GUID-2"}}, []int{3}},
+		{"ids and names", &Controller{
+			ApplicationFilter:       []string{"1", "4"},
+			ApplicationNameIncludes: mustParseNamePatterns(t, "checkout-*"),
+		}, []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.controller.Name = config.DefaultControllerName
+			tt.controller.Client = client
+
+			applications, err := tt.controller.listApplications(context.Background())
+			require.NoError(t, err)
+			ids := make([]int, 0, len(applications))
+			for _, app := range applications {
+				ids = append(ids, app.ID)
+			}
+			require.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+type countingApplicationsClient struct {
+	AppDynamicsClient
+	calls atomic.Int32
+}
+
+func (c *countingApplicationsClient) ListApplications(context.Context) ([]appdclient.Application, error) {
+	c.calls.Add(1)
+	return []appdclient.Application{{ID: 1, Name: "checkout"}, {ID: 2, Name: "ledger"}}, nil
+}
+
+func TestListApplications_SharesCachedApplications(t *testing.T) {
+	config.Config.ApplicationCacheTtl = time.Minute
+	t.Cleanup(func() { config.Config.ApplicationCacheTtl = 0 })
+
+	client := &countingApplicationsClient{}
+	controller := &Controller{Name: config.DefaultControllerName, Client: client}
+
+	applications, err := controller.listApplications(context.Background())
+	require.NoError(t, err)
+	require.Len(t, applications, 2)
+
+	// A filter changed in between still applies to the cached applications.
+	controller.ApplicationFilter = []string{"2"}
+	applications, err = controller.listApplications(context.Background())
+	require.NoError(t, err)
+	require.Len(t, applications, 1)
+	require.Equal(t, int32(1), client.calls.Load())
+
+	config.Config.ApplicationCacheTtl = 0
+	_, err = controller.listApplications(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(2), client.calls.Load())
+}
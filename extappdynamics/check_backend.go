@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"errors"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+)
+
+// NewBackendCheckAction checks how the calling application sees a backend, e.g. whether it notices the latency injected
+// towards a dependency.
+func NewBackendCheckAction(controllers Controllers) action_kit_sdk.Action[MetricCheckState] {
+	return newMetricCheckAction(controllers, metricCheck{
+		id:              backendTargetType + ".check",
+		label:           "Backend Check",
+		description:     "Verify the errors or response time of a backend as seen by the application calling it in AppDynamics.",
+		widgetTitle:     "AppDynamics Backend Metric",
+		spanName:        "backend-check",
+		targetType:      backendTargetType,
+		targetAttribute: BackendAttribute,
+		selectionTemplates: []action_kit_api.TargetSelectionTemplate{
+			{
+				Label:       "by application and backend name",
+				Description: new("Find backend by the name of its application and its name"),
+				Query:       "appdynamics.backend.application.name=\"\" and appdynamics.backend.name=\"\"",
+			},
+		},
+		metrics: []metricOption{
+			{label: "Errors per minute", path: "Errors per Minute"},
+			{label: "Average response time (ms)", path: "Average Response Time (ms)"},
+			{label: "Calls per minute", path: "Calls per Minute"},
+		},
+		metricScope: func(attributes map[string][]string) (string, string, error) {
+			appId := attributes[BackendAttribute+AttributeAppID]
+			if len(appId) == 0 {
+				return "", "", errors.New("target is missing the 'appdynamics.backend.application.id' attribute")
+			}
+			name := attributes[BackendAttribute+".name"]
+			if len(name) == 0 {
+				return "", "", errors.New("target is missing the 'appdynamics.backend.name' attribute")
+			}
+			return appId[0], "Backends|" + name[0], nil
+		},
+	})
+}
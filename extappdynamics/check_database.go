@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"errors"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+)
+
+// databaseApplication is the application the controller reports the metrics of Database Visibility collectors in.
+const databaseApplication = "Database Monitoring"
+
+// NewDatabaseCheckAction checks the metrics of a database, e.g. its connections while its connectivity is attacked.
+func NewDatabaseCheckAction(controllers Controllers) action_kit_sdk.Action[MetricCheckState] {
+	return newMetricCheckAction(controllers, metricCheck{
+		id:              databaseTargetType + ".check",
+		label:           "Database Check",
+		description:     "Verify the connections, execution time or wait states of a database monitored by AppDynamics Database Visibility.",
+		widgetTitle:     "AppDynamics Database Metric",
+		spanName:        "database-check",
+		targetType:      databaseTargetType,
+		targetAttribute: DatabaseAttribute,
+		selectionTemplates: []action_kit_api.TargetSelectionTemplate{
+			{
+				Label:       "by collector name",
+				Description: new("Find database by the name of its collector"),
+				Query:       "appdynamics.database.name=\"\"",
+			},
+		},
+		metrics: []metricOption{
+			{label: "Connections", path: "KPI|Number of Connections"},
+			{label: "Time spent in executions (s)", path: "KPI|Time Spent in Executions (s)"},
+			{label: "Calls per minute", path: "KPI|Calls per Minute"},
+			// The wildcard matches every wait state, their times are summed up.
+			{label: "Time spent in query wait states (s)", path: "Wait States|*"},
+		},
+		metricScope: func(attributes map[string][]string) (string, string, error) {
+			name := attributes[DatabaseAttribute+".name"]
+			if len(name) == 0 {
+				return "", "", errors.New("target is missing the 'appdynamics.database.name' attribute")
+			}
+			return databaseApplication, "Databases|" + name[0], nil
+		},
+	})
+}
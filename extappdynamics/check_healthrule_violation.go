@@ -11,19 +11,21 @@ import (
 	"context"
 	"fmt"
 	"strconv"
-	"strings"
 	"time"
 
-	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog/log"
 	"github.com/steadybit/action-kit/go/action_kit_api/v2"
 	"github.com/steadybit/action-kit/go/action_kit_sdk"
-	"github.com/steadybit/extension-appdynamics/config"
+	"github.com/steadybit/extension-appdynamics/appdclient"
 	extension_kit "github.com/steadybit/extension-kit"
 	"github.com/steadybit/extension-kit/extbuild"
 	"github.com/steadybit/extension-kit/extutil"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-type HealthRuleStateCheckAction struct{}
+type HealthRuleStateCheckAction struct {
+	controllers Controllers
+}
 
 // Make sure action implements all required interfaces
 var (
@@ -35,6 +37,8 @@ type HealthRuleCheckState struct {
 	HealthRuleId          string
 	HealthRuleName        string
 	HealthRuleApplication string
+	Controller            string
+	ControllerUrl         string
 	End                   time.Time
 	IsViolationExpected   bool
 	StateCheckMode        string
@@ -44,10 +48,23 @@ type HealthRuleCheckState struct {
 	// that a deviating state was observed during the step so the failure can be reported once the step ends.
 	DeviationSeen  bool
 	DeviationTitle string
+	// FailedPollTolerance is the number of consecutive polls that may fail to retrieve the violations before the
+	// check errors. Failed polls within the tolerance are reported as 'unknown' and keep the last known state.
+	FailedPollTolerance    int
+	ConsecutiveFailedPolls int
+	Trace                  ExecutionTrace
+}
+
+func (s *HealthRuleCheckState) spanAttributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("appdynamics.controller", s.Controller),
+		attribute.String("appdynamics.application.id", s.HealthRuleApplication),
+		attribute.String("appdynamics.health-rule.id", s.HealthRuleId),
+	}
 }
 
-func NewHealthRuleStateCheckAction() action_kit_sdk.Action[HealthRuleCheckState] {
-	return &HealthRuleStateCheckAction{}
+func NewHealthRuleStateCheckAction(controllers Controllers) action_kit_sdk.Action[HealthRuleCheckState] {
+	return &HealthRuleStateCheckAction{controllers: controllers}
 }
 
 func (m *HealthRuleStateCheckAction) NewEmptyState() HealthRuleCheckState {
@@ -134,6 +151,16 @@ func (m *HealthRuleStateCheckAction) Describe() action_kit_api.ActionDescription
 				Required:     new(false),
 				Order:        new(4),
 			},
+			{
+				Name:         "failedPollTolerance",
+				Label:        "Tolerated failed polls",
+				Description:  new("Number of consecutive polls that may fail to retrieve the violations from AppDynamics, e.g. due to a flaky controller, before the check errors. Failed polls are shown as 'unknown' and do not change the last known state."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("0"),
+				Advanced:     new(true),
+				Required:     new(false),
+				Order:        new(5),
+			},
 		},
 		Widgets: new([]action_kit_api.Widget{
 			action_kit_api.StateOverTimeWidget{
@@ -165,7 +192,11 @@ func (m *HealthRuleStateCheckAction) Describe() action_kit_api.ActionDescription
 	}
 }
 
-func (m *HealthRuleStateCheckAction) Prepare(_ context.Context, state *HealthRuleCheckState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+func (m *HealthRuleStateCheckAction) Prepare(ctx context.Context, state *HealthRuleCheckState, request action_kit_api.PrepareActionRequestBody) (_ *action_kit_api.PrepareResult, err error) {
+	state.Trace = newExecutionTrace(request)
+	_, span := startActionSpan(ctx, "health-rule-check.prepare", state.Trace)
+	defer func() { endActionSpan(span, nil, err) }()
+
 	now := time.Now()
 	HealthRuleId := request.Target.Attributes[HealthRuleAttribute+".id"]
 	if len(HealthRuleId) == 0 {
@@ -200,18 +231,42 @@ func (m *HealthRuleStateCheckAction) Prepare(_ context.Context, state *HealthRul
 	if request.Config["failEarly"] != nil {
 		state.FailEarly = extutil.ToBool(request.Config["failEarly"])
 	}
+	if request.Config["failedPollTolerance"] != nil {
+		state.FailedPollTolerance = extutil.ToInt(request.Config["failedPollTolerance"])
+	}
+
+	controller, err := m.controllers.get(getControllerName(request.Target.Attributes, HealthRuleAttribute+AttributeController))
+	if err != nil {
+		return nil, new(extension_kit.ToError("Failed to resolve the AppDynamics controller of the target.", err))
+	}
 
+	state.Controller = controller.Name
+	state.ControllerUrl = controller.Client.BaseUrl()
 	state.HealthRuleName = healthRuleName[0]
 	state.HealthRuleApplication = healthRuleApplication[0]
 	state.End = end
 	state.IsViolationExpected = expectedViolation
 	state.StateCheckMode = stateCheckMode
+	span.SetAttributes(state.spanAttributes()...)
 
 	return nil, nil
 }
 
-func (m *HealthRuleStateCheckAction) Start(ctx context.Context, state *HealthRuleCheckState) (*action_kit_api.StartResult, error) {
-	statusResult, err := HealthRuleCheckStatus(ctx, state, RestyClient)
+func (m *HealthRuleStateCheckAction) Start(ctx context.Context, state *HealthRuleCheckState) (result *action_kit_api.StartResult, err error) {
+	ctx, span := startActionSpan(ctx, "health-rule-check.start", state.Trace, state.spanAttributes()...)
+	defer func() {
+		var actionError *action_kit_api.ActionKitError
+		if result != nil {
+			actionError = result.Error
+		}
+		endActionSpan(span, actionError, err)
+	}()
+
+	controller, err := m.controllers.get(state.Controller)
+	if err != nil {
+		return nil, new(extension_kit.ToError("Failed to resolve the AppDynamics controller of the target.", err))
+	}
+	statusResult, err := HealthRuleCheckStatus(ctx, state, controller.Client)
 	if statusResult == nil {
 		return nil, err
 	}
@@ -223,35 +278,50 @@ func (m *HealthRuleStateCheckAction) Start(ctx context.Context, state *HealthRul
 	}, err
 }
 
-func (m *HealthRuleStateCheckAction) Status(ctx context.Context, state *HealthRuleCheckState) (*action_kit_api.StatusResult, error) {
-	return HealthRuleCheckStatus(ctx, state, RestyClient)
+func (m *HealthRuleStateCheckAction) Status(ctx context.Context, state *HealthRuleCheckState) (result *action_kit_api.StatusResult, err error) {
+	ctx, span := startActionSpan(ctx, "health-rule-check.status", state.Trace, state.spanAttributes()...)
+	defer func() {
+		var actionError *action_kit_api.ActionKitError
+		if result != nil {
+			actionError = result.Error
+		}
+		endActionSpan(span, actionError, err)
+	}()
+
+	controller, err := m.controllers.get(state.Controller)
+	if err != nil {
+		return nil, new(extension_kit.ToError("Failed to resolve the AppDynamics controller of the target.", err))
+	}
+	return HealthRuleCheckStatus(ctx, state, controller.Client)
 }
 
-func HealthRuleCheckStatus(ctx context.Context, state *HealthRuleCheckState, client *resty.Client) (*action_kit_api.StatusResult, error) {
+func HealthRuleCheckStatus(ctx context.Context, state *HealthRuleCheckState, client AppDynamicsClient) (*action_kit_api.StatusResult, error) {
 	now := time.Now()
-	nowStr := strconv.FormatInt(now.UnixMilli(), 10) // base 10
-	endStr := strconv.FormatInt(state.End.UnixMilli(), 10)
+	start := now
 	completed := time.Now().After(state.End)
 	if completed {
-		nowStr = strconv.FormatInt(state.End.UnixMilli(), 10)
+		start = state.End
 	}
-	var violations []Violation
-	uri := "/controller/rest/applications/" + state.HealthRuleApplication + "/problems/healthrule-violations?output=JSON&time-range-type=BETWEEN_TIMES&start-time=" + nowStr + "&end-time=" + endStr
-	res, err := client.R().
-		SetContext(ctx).
-		SetResult(&violations).
-		Get(uri)
-
+	violations, err := client.GetViolations(ctx, state.HealthRuleApplication, start, state.End)
 	if err != nil {
-		return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to retrieve health rules from AppDynamics for Application ID %s.", state.HealthRuleApplication), err))
-	}
-
-	if !res.IsSuccess() {
-		return nil, new(extension_kit.ToError(fmt.Sprintf("AppDynamics API responded with unexpected status code %d while retrieving health rule violations for Application ID %s. Full response: %v", res.StatusCode(), state.HealthRuleApplication, res.String()), nil))
+		state.ConsecutiveFailedPolls++
+		if state.ConsecutiveFailedPolls > state.FailedPollTolerance {
+			healthRuleCheckPolls.WithLabelValues(pollOutcomeFailure).Inc()
+			return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to retrieve health rule violations from AppDynamics for Application ID %s.", state.HealthRuleApplication), err))
+		}
+		log.Warn().Err(err).Msgf("Failed to retrieve health rule violations for Application ID %s (%d of %d tolerated failed polls).", state.HealthRuleApplication, state.ConsecutiveFailedPolls, state.FailedPollTolerance)
+		healthRuleCheckPolls.WithLabelValues(pollOutcomeToleratedFailure).Inc()
+		return unknownStateCheckStatus(state, completed, err, now), nil
 	}
+	state.ConsecutiveFailedPolls = 0
 
 	var checkError *action_kit_api.ActionKitError
 	healthRuleHasViolations, currentViolation := hasViolations(violations, state.HealthRuleName)
+	if healthRuleHasViolations {
+		healthRuleCheckPolls.WithLabelValues(pollOutcomeViolations).Inc()
+	} else {
+		healthRuleCheckPolls.WithLabelValues(pollOutcomeNoViolations).Inc()
+	}
 
 	if state.StateCheckMode == StateCheckModeAllTheTime {
 		if !state.IsViolationExpected == healthRuleHasViolations {
@@ -296,7 +366,7 @@ func HealthRuleCheckStatus(ctx context.Context, state *HealthRuleCheckState, cli
 	}
 
 	metrics := []action_kit_api.Metric{
-		*toMetric(state.HealthRuleId, state.HealthRuleName, state.HealthRuleApplication, currentViolation, healthRuleHasViolations, now),
+		*toMetric(state.ControllerUrl, state.HealthRuleId, state.HealthRuleName, state.HealthRuleApplication, currentViolation, healthRuleHasViolations, now),
 	}
 
 	return &action_kit_api.StatusResult{
@@ -306,7 +376,38 @@ func HealthRuleCheckStatus(ctx context.Context, state *HealthRuleCheckState, cli
 	}, nil
 }
 
-func toMetric(healthRuleID string, healthRuleName string, appID string, violation *Violation, hasViolations bool, now time.Time) *action_kit_api.Metric {
+// unknownStateCheckStatus reports a poll whose violations could not be retrieved. The state is not evaluated, but a
+// check ending with this poll is still failed based on the states observed before.
+func unknownStateCheckStatus(state *HealthRuleCheckState, completed bool, err error, now time.Time) *action_kit_api.StatusResult {
+	var checkError *action_kit_api.ActionKitError
+	if completed {
+		if state.StateCheckMode == StateCheckModeAllTheTime && !state.FailEarly && state.DeviationSeen {
+			checkError = new(action_kit_api.ActionKitError{
+				Title:  state.DeviationTitle,
+				Status: extutil.Ptr(action_kit_api.Failed),
+			})
+		} else if state.StateCheckMode == StateCheckModeAtLeastOnce && !state.StateCheckSuccess {
+			checkError = new(action_kit_api.ActionKitError{
+				Title: fmt.Sprintf("HealthRule '%s' never had violations '%t' as expected once.",
+					state.HealthRuleName,
+					state.IsViolationExpected),
+				Status: extutil.Ptr(action_kit_api.Failed),
+			})
+		}
+	}
+
+	metric := toMetric(state.ControllerUrl, state.HealthRuleId, state.HealthRuleName, state.HealthRuleApplication, nil, false, now)
+	metric.Metric["state"] = "unknown"
+	metric.Metric["tooltip"] = fmt.Sprintf("Health rule state unknown: %s", err.Error())
+
+	return &action_kit_api.StatusResult{
+		Completed: completed,
+		Error:     checkError,
+		Metrics:   new([]action_kit_api.Metric{*metric}),
+	}
+}
+
+func toMetric(controllerUrl string, healthRuleID string, healthRuleName string, appID string, violation *appdclient.Violation, hasViolations bool, now time.Time) *action_kit_api.Metric {
 	var tooltip string
 	var state string
 
@@ -317,9 +418,9 @@ func toMetric(healthRuleID string, healthRuleName string, appID string, violatio
 		state = "danger"
 	}
 
-	url := fmt.Sprintf("%s/controller/#/location=APP_DASHBOARD&timeRange=last_1_hour.BEFORE_NOW.-1.-1.60&application=%s&dashboardMode=force", strings.TrimRight(config.Config.ApiBaseUrl, "/"), appID)
+	url := fmt.Sprintf("%s/controller/#/location=APP_DASHBOARD&timeRange=last_1_hour.BEFORE_NOW.-1.-1.60&application=%s&dashboardMode=force", controllerUrl, appID)
 	if violation != nil {
-		url = fmt.Sprintf("%s/controller/#/location=APP_INCIDENT_DETAIL_MODAL&timeRange=last_1_hour.BEFORE_NOW.-1.-1.60&application=%s&incident=%d&incidentTime=%s", strings.TrimRight(config.Config.ApiBaseUrl, "/"), appID, violation.ID, strconv.FormatInt(now.UnixMilli(), 10))
+		url = fmt.Sprintf("%s/controller/#/location=APP_INCIDENT_DETAIL_MODAL&timeRange=last_1_hour.BEFORE_NOW.-1.-1.60&application=%s&incident=%d&incidentTime=%s", controllerUrl, appID, violation.ID, strconv.FormatInt(now.UnixMilli(), 10))
 	}
 
 	return new(action_kit_api.Metric{
@@ -336,7 +437,7 @@ func toMetric(healthRuleID string, healthRuleName string, appID string, violatio
 	})
 }
 
-func hasViolations(violations []Violation, healthRuleName string) (bool, *Violation) {
+func hasViolations(violations []appdclient.Violation, healthRuleName string) (bool, *appdclient.Violation) {
 	for _, violation := range violations {
 		if violation.Name == healthRuleName {
 			return true, &violation
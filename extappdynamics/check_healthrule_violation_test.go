@@ -9,11 +9,12 @@ import (
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/steadybit/extension-appdynamics/appdclient"
 )
 
 // TestHasViolations verifies the hasViolations helper.
 func TestHasViolations(t *testing.T) {
-	violations := []Violation{
+	violations := []appdclient.Violation{
 		{Name: "foo"},
 		{Name: "bar"},
 	}
@@ -35,7 +36,7 @@ func TestHealthRuleCheckStatus_NoViolations_AllTheTime(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	client := resty.New().SetBaseURL(ts.URL)
+	client := appdclient.New(resty.New().SetBaseURL(ts.URL))
 	state := HealthRuleCheckState{
 		HealthRuleName:        "foo",
 		HealthRuleApplication: "app",
@@ -71,7 +72,7 @@ func TestHealthRuleCheckStatus_ViolationsUnexpected_AllTheTime(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	client := resty.New().SetHostURL(ts.URL)
+	client := appdclient.New(resty.New().SetHostURL(ts.URL))
 	state := HealthRuleCheckState{
 		HealthRuleName:        "foo",
 		HealthRuleApplication: "app",
@@ -103,7 +104,7 @@ func TestHealthRuleCheckStatus_AllTheTime_FailAtEnd(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	client := resty.New().SetHostURL(ts.URL)
+	client := appdclient.New(resty.New().SetHostURL(ts.URL))
 
 	// Not yet completed: a deviation is observed but must not fail early.
 	state := HealthRuleCheckState{
@@ -147,7 +148,7 @@ func TestHealthRuleCheckStatus_AtLeastOnce_Success(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	client := resty.New().SetBaseURL(ts.URL)
+	client := appdclient.New(resty.New().SetBaseURL(ts.URL))
 	state := HealthRuleCheckState{
 		HealthRuleName:        "foo",
 		HealthRuleApplication: "app",
@@ -176,7 +177,7 @@ func TestHealthRuleCheckStatus_AtLeastOnce_Failure(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	client := resty.New().SetHostURL(ts.URL)
+	client := appdclient.New(resty.New().SetHostURL(ts.URL))
 	state := HealthRuleCheckState{
 		HealthRuleName:        "foo",
 		HealthRuleApplication: "app",
@@ -196,3 +197,99 @@ func TestHealthRuleCheckStatus_AtLeastOnce_Failure(t *testing.T) {
 		t.Error("expected an error due to missing expected violation, got nil")
 	}
 }
+
+// failingViolationsClient fails to retrieve violations for the given number of calls, then reports no violations.
+type failingViolationsClient struct {
+	AppDynamicsClient
+	failures int
+}
+
+func (c *failingViolationsClient) GetViolations(context.Context, string, time.Time, time.Time) ([]appdclient.Violation, error) {
+	if c.failures > 0 {
+		c.failures--
+		return nil, &appdclient.StatusError{StatusCode: http.StatusServiceUnavailable}
+	}
+	return []appdclient.Violation{}, nil
+}
+
+// TestHealthRuleCheckStatus_ToleratesFailedPolls tests that failed polls within the tolerance are reported as unknown.
+func TestHealthRuleCheckStatus_ToleratesFailedPolls(t *testing.T) {
+	client := &failingViolationsClient{failures: 2}
+	state := HealthRuleCheckState{
+		HealthRuleName:        "foo",
+		HealthRuleApplication: "app",
+		End:                   time.Now().Add(time.Minute),
+		IsViolationExpected:   false,
+		StateCheckMode:        StateCheckModeAllTheTime,
+		FailEarly:             true,
+		FailedPollTolerance:   2,
+	}
+
+	for range 2 {
+		res, err := HealthRuleCheckStatus(context.Background(), &state, client)
+		if err != nil {
+			t.Fatalf("unexpected error within tolerance: %v", err)
+		}
+		if res.Error != nil {
+			t.Errorf("expected no check error for an unknown state, got %v", res.Error)
+		}
+		if (*res.Metrics)[0].Metric["state"] != "unknown" {
+			t.Errorf("expected metric state \"unknown\", got %q", (*res.Metrics)[0].Metric["state"])
+		}
+	}
+
+	res, err := HealthRuleCheckStatus(context.Background(), &state, client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if (*res.Metrics)[0].Metric["state"] != "success" {
+		t.Errorf("expected metric state \"success\", got %q", (*res.Metrics)[0].Metric["state"])
+	}
+	if state.ConsecutiveFailedPolls != 0 {
+		t.Errorf("expected failed polls to be reset, got %d", state.ConsecutiveFailedPolls)
+	}
+}
+
+// TestHealthRuleCheckStatus_ExceedsFailedPollTolerance tests that the check errors once the tolerance is exceeded.
+func TestHealthRuleCheckStatus_ExceedsFailedPollTolerance(t *testing.T) {
+	client := &failingViolationsClient{failures: 2}
+	state := HealthRuleCheckState{
+		HealthRuleName:        "foo",
+		HealthRuleApplication: "app",
+		End:                   time.Now().Add(time.Minute),
+		StateCheckMode:        StateCheckModeAllTheTime,
+		FailedPollTolerance:   1,
+	}
+
+	if _, err := HealthRuleCheckStatus(context.Background(), &state, client); err != nil {
+		t.Fatalf("unexpected error within tolerance: %v", err)
+	}
+	if _, err := HealthRuleCheckStatus(context.Background(), &state, client); err == nil {
+		t.Error("expected an error once the tolerance is exceeded")
+	}
+}
+
+// TestHealthRuleCheckStatus_AtLeastOnce_UnknownAtEnd tests that a check ending with an unknown poll is evaluated on the
+// states observed before.
+func TestHealthRuleCheckStatus_AtLeastOnce_UnknownAtEnd(t *testing.T) {
+	client := &failingViolationsClient{failures: 1}
+	state := HealthRuleCheckState{
+		HealthRuleName:        "foo",
+		HealthRuleApplication: "app",
+		End:                   time.Now().Add(-time.Second),
+		IsViolationExpected:   true,
+		StateCheckMode:        StateCheckModeAtLeastOnce,
+		FailedPollTolerance:   1,
+	}
+
+	res, err := HealthRuleCheckStatus(context.Background(), &state, client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Completed {
+		t.Error("expected Completed to be true")
+	}
+	if res.Error == nil {
+		t.Error("expected an error as the expected violation was never observed")
+	}
+}
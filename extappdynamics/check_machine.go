@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"errors"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+)
+
+// simApplication is the application the controller reports the metrics of Server Visibility (SIM) machines in.
+const simApplication = "Server & Infrastructure Monitoring"
+
+// NewMachineCheckAction checks the hardware metrics of a machine, e.g. its CPU usage during a CPU stress attack.
+func NewMachineCheckAction(controllers Controllers) action_kit_sdk.Action[MetricCheckState] {
+	return newMetricCheckAction(controllers, metricCheck{
+		id:              machineTargetType + ".check",
+		label:           "Machine Check",
+		description:     "Verify the CPU, memory or disk usage of a machine monitored by AppDynamics Server Visibility.",
+		widgetTitle:     "AppDynamics Machine Metric",
+		spanName:        "machine-check",
+		targetType:      machineTargetType,
+		targetAttribute: MachineAttribute,
+		selectionTemplates: []action_kit_api.TargetSelectionTemplate{
+			{
+				Label:       "by hostname",
+				Description: new("Find machine by hostname"),
+				Query:       "appdynamics.machine.hostname=\"\"",
+			},
+		},
+		metrics: []metricOption{
+			{label: "CPU busy %", path: "CPU|%Busy"},
+			{label: "Memory used %", path: "Memory|Used %"},
+			{label: "Disk KB read/sec", path: "Disks|KB read/sec"},
+			{label: "Disk KB written/sec", path: "Disks|KB written/sec"},
+		},
+		metricScope: func(attributes map[string][]string) (string, string, error) {
+			name := attributes[MachineAttribute+".name"]
+			if len(name) == 0 {
+				return "", "", errors.New("target is missing the 'appdynamics.machine.name' attribute")
+			}
+			return simApplication, "Application Infrastructure Performance|Root|Individual Nodes|" + name[0] + "|Hardware Resources", nil
+		},
+	})
+}
@@ -0,0 +1,455 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	MetricExpectationAbove = "above"
+	MetricExpectationBelow = "below"
+
+	// metricCheckWindow is how far back the latest value of a metric is looked up. The controller reports metrics once
+	// a minute and with some delay.
+	metricCheckWindow = 5 * time.Minute
+)
+
+// metricOption is a metric the user can choose to check. Its path is relative to the metric path of the target.
+type metricOption struct {
+	label string
+	path  string
+}
+
+// metricCheck describes a check on the metrics of a target type, e.g. the CPU usage of a machine. The evaluation of the
+// metrics is shared by all metric checks.
+type metricCheck struct {
+	id          string
+	label       string
+	description string
+	widgetTitle string
+	// spanName prefixes the names of the spans of the action calls, e.g. "machine-check".
+	spanName   string
+	targetType string
+	// targetAttribute is the attribute prefix of the target type. The targets need the attributes ".id", ".name" and
+	// ".controller".
+	targetAttribute    string
+	selectionTemplates []action_kit_api.TargetSelectionTemplate
+	metrics            []metricOption
+	// metricScope returns the application the metrics of the target belong to and the metric path of the target, which
+	// the paths of the metric options are relative to.
+	metricScope func(attributes map[string][]string) (applicationId string, metricPath string, err error)
+}
+
+type MetricCheckAction struct {
+	controllers Controllers
+	check       metricCheck
+}
+
+// Make sure action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[MetricCheckState]           = (*MetricCheckAction)(nil)
+	_ action_kit_sdk.ActionWithStatus[MetricCheckState] = (*MetricCheckAction)(nil)
+)
+
+type MetricCheckState struct {
+	Controller      string
+	ApplicationId   string
+	MetricPath      string
+	MetricLabel     string
+	TargetAttribute string
+	TargetId        string
+	TargetName      string
+	End             time.Time
+	Expectation     string
+	Threshold       int64
+	StateCheckMode  string
+	// StateCheckSuccess, DeviationSeen and DeviationTitle have the same meaning as in the HealthRuleCheckState.
+	StateCheckSuccess      bool
+	FailEarly              bool
+	DeviationSeen          bool
+	DeviationTitle         string
+	FailedPollTolerance    int
+	ConsecutiveFailedPolls int
+	Trace                  ExecutionTrace
+}
+
+func (s *MetricCheckState) spanAttributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("appdynamics.controller", s.Controller),
+		attribute.String("appdynamics.application.id", s.ApplicationId),
+		attribute.String("appdynamics.metric.path", s.MetricPath),
+	}
+}
+
+func newMetricCheckAction(controllers Controllers, check metricCheck) action_kit_sdk.Action[MetricCheckState] {
+	return &MetricCheckAction{controllers: controllers, check: check}
+}
+
+func (m *MetricCheckAction) NewEmptyState() MetricCheckState {
+	return MetricCheckState{}
+}
+
+func (m *MetricCheckAction) Describe() action_kit_api.ActionDescription {
+	metricOptions := make([]action_kit_api.ParameterOption, 0, len(m.check.metrics))
+	for _, metric := range m.check.metrics {
+		metricOptions = append(metricOptions, action_kit_api.ExplicitParameterOption{Label: metric.label, Value: metric.path})
+	}
+
+	return action_kit_api.ActionDescription{
+		Id:          m.check.id,
+		Label:       m.check.label,
+		Description: m.check.description,
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(appDynamicsTargetIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType:          m.check.targetType,
+			QuantityRestriction: extutil.Ptr(action_kit_api.QuantityRestrictionAll),
+			SelectionTemplates:  new(m.check.selectionTemplates),
+		}),
+		Technology:  new("AppDynamics"),
+		Kind:        action_kit_api.Check,
+		TimeControl: action_kit_api.TimeControlInternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new(""),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Order:        new(1),
+				Required:     new(true),
+			},
+			{
+				Name:         "metric",
+				Label:        "Metric",
+				Description:  new("The metric to check."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				Options:      new(metricOptions),
+				DefaultValue: new(m.check.metrics[0].path),
+				Required:     new(true),
+				Order:        new(2),
+			},
+			{
+				Name:        "expectation",
+				Label:       "Expected Value",
+				Description: new("Should the latest value of the metric be above or below the threshold?"),
+				Type:        action_kit_api.ActionParameterTypeString,
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "Above threshold",
+						Value: MetricExpectationAbove,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Below threshold",
+						Value: MetricExpectationBelow,
+					},
+				}),
+				DefaultValue: new(MetricExpectationAbove),
+				Required:     new(true),
+				Order:        new(3),
+			},
+			{
+				Name:         "threshold",
+				Label:        "Threshold",
+				Description:  new("The threshold the metric is compared with, in the unit of the metric."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("0"),
+				Required:     new(true),
+				Order:        new(4),
+			},
+			{
+				Name:         "stateCheckMode",
+				Label:        "State Check Mode",
+				Description:  new("How often should the state be checked ?"),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(StateCheckModeAllTheTime),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "All the time",
+						Value: StateCheckModeAllTheTime,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "At least once",
+						Value: StateCheckModeAtLeastOnce,
+					},
+				}),
+				Required: new(true),
+				Order:    new(5),
+			},
+			{
+				Name:         "failEarly",
+				Label:        "Fail early",
+				Description:  new("If enabled, the check fails as soon as a deviating value is observed. If disabled, the check keeps collecting values for the whole duration and only fails at the end of the step. Only affects the 'All the time' mode."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("true"),
+				Advanced:     new(true),
+				Required:     new(false),
+				Order:        new(6),
+			},
+			{
+				Name:         "failedPollTolerance",
+				Label:        "Tolerated failed polls",
+				Description:  new("Number of consecutive polls that may fail to retrieve the metric from AppDynamics before the check errors. Failed polls are shown as 'unknown' and do not change the last known state."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("0"),
+				Advanced:     new(true),
+				Required:     new(false),
+				Order:        new(7),
+			},
+		},
+		Widgets: new([]action_kit_api.Widget{
+			action_kit_api.StateOverTimeWidget{
+				Type:  action_kit_api.ComSteadybitWidgetStateOverTime,
+				Title: m.check.widgetTitle,
+				Identity: action_kit_api.StateOverTimeWidgetIdentityConfig{
+					From: m.check.targetAttribute + ".id",
+				},
+				Label: action_kit_api.StateOverTimeWidgetLabelConfig{
+					From: m.check.targetAttribute + ".name",
+				},
+				State: action_kit_api.StateOverTimeWidgetStateConfig{
+					From: "state",
+				},
+				Tooltip: action_kit_api.StateOverTimeWidgetTooltipConfig{
+					From: "tooltip",
+				},
+			},
+		}),
+		// The controller reports metrics once a minute, polling more often would only return the same values.
+		Status: new(action_kit_api.MutatingEndpointReferenceWithCallInterval{
+			CallInterval: new("10s"),
+		}),
+	}
+}
+
+func (m *MetricCheckAction) Prepare(ctx context.Context, state *MetricCheckState, request action_kit_api.PrepareActionRequestBody) (_ *action_kit_api.PrepareResult, err error) {
+	state.Trace = newExecutionTrace(request)
+	_, span := startActionSpan(ctx, m.check.spanName+".prepare", state.Trace)
+	defer func() { endActionSpan(span, nil, err) }()
+
+	targetId := request.Target.Attributes[m.check.targetAttribute+".id"]
+	if len(targetId) == 0 {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Target is missing the '%s.id' attribute.", m.check.targetAttribute), nil))
+	}
+	targetName := request.Target.Attributes[m.check.targetAttribute+".name"]
+	if len(targetName) == 0 {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Target is missing the '%s.name' attribute.", m.check.targetAttribute), nil))
+	}
+	applicationId, metricPath, err := m.check.metricScope(request.Target.Attributes)
+	if err != nil {
+		return nil, new(extension_kit.ToError("Failed to determine the metrics of the target.", err))
+	}
+
+	metric := extutil.ToString(request.Config["metric"])
+	var metricLabel string
+	for _, option := range m.check.metrics {
+		if option.path == metric {
+			metricLabel = option.label
+		}
+	}
+	if metricLabel == "" {
+		return nil, new(extension_kit.ToError(fmt.Sprintf("Unknown metric '%s'.", metric), nil))
+	}
+
+	controller, err := m.controllers.get(getControllerName(request.Target.Attributes, m.check.targetAttribute+AttributeController))
+	if err != nil {
+		return nil, new(extension_kit.ToError("Failed to resolve the AppDynamics controller of the target.", err))
+	}
+
+	duration := request.Config["duration"].(float64)
+	state.Controller = controller.Name
+	state.ApplicationId = applicationId
+	state.MetricPath = metricPath + "|" + metric
+	state.MetricLabel = metricLabel
+	state.TargetAttribute = m.check.targetAttribute
+	state.TargetId = targetId[0]
+	state.TargetName = targetName[0]
+	state.End = time.Now().Add(time.Millisecond * time.Duration(duration))
+	state.Expectation = MetricExpectationAbove
+	if request.Config["expectation"] != nil {
+		state.Expectation = extutil.ToString(request.Config["expectation"])
+	}
+	state.Threshold = extutil.ToInt64(request.Config["threshold"])
+	state.StateCheckMode = StateCheckModeAllTheTime
+	if request.Config["stateCheckMode"] != nil {
+		state.StateCheckMode = extutil.ToString(request.Config["stateCheckMode"])
+	}
+	state.FailEarly = true
+	if request.Config["failEarly"] != nil {
+		state.FailEarly = extutil.ToBool(request.Config["failEarly"])
+	}
+	if request.Config["failedPollTolerance"] != nil {
+		state.FailedPollTolerance = extutil.ToInt(request.Config["failedPollTolerance"])
+	}
+	span.SetAttributes(state.spanAttributes()...)
+
+	return nil, nil
+}
+
+func (m *MetricCheckAction) Start(ctx context.Context, state *MetricCheckState) (result *action_kit_api.StartResult, err error) {
+	ctx, span := startActionSpan(ctx, m.check.spanName+".start", state.Trace, state.spanAttributes()...)
+	defer func() {
+		var actionError *action_kit_api.ActionKitError
+		if result != nil {
+			actionError = result.Error
+		}
+		endActionSpan(span, actionError, err)
+	}()
+
+	controller, err := m.controllers.get(state.Controller)
+	if err != nil {
+		return nil, new(extension_kit.ToError("Failed to resolve the AppDynamics controller of the target.", err))
+	}
+	statusResult, err := MetricCheckStatus(ctx, state, controller.Client)
+	if statusResult == nil {
+		return nil, err
+	}
+	return &action_kit_api.StartResult{
+		Error:    statusResult.Error,
+		Messages: statusResult.Messages,
+		Metrics:  statusResult.Metrics,
+	}, err
+}
+
+func (m *MetricCheckAction) Status(ctx context.Context, state *MetricCheckState) (result *action_kit_api.StatusResult, err error) {
+	ctx, span := startActionSpan(ctx, m.check.spanName+".status", state.Trace, state.spanAttributes()...)
+	defer func() {
+		var actionError *action_kit_api.ActionKitError
+		if result != nil {
+			actionError = result.Error
+		}
+		endActionSpan(span, actionError, err)
+	}()
+
+	controller, err := m.controllers.get(state.Controller)
+	if err != nil {
+		return nil, new(extension_kit.ToError("Failed to resolve the AppDynamics controller of the target.", err))
+	}
+	return MetricCheckStatus(ctx, state, controller.Client)
+}
+
+// MetricCheckStatus retrieves the latest value of the metric and evaluates it in the state check mode. Without a value,
+// e.g. because the controller couldn't be reached or didn't report the metric yet, the state is unknown.
+func MetricCheckStatus(ctx context.Context, state *MetricCheckState, client AppDynamicsClient) (*action_kit_api.StatusResult, error) {
+	now := time.Now()
+	completed := now.After(state.End)
+
+	metricData, err := client.GetMetricData(ctx, state.ApplicationId, state.MetricPath, now.Add(-metricCheckWindow), now)
+	if err != nil {
+		state.ConsecutiveFailedPolls++
+		if state.ConsecutiveFailedPolls > state.FailedPollTolerance {
+			return nil, new(extension_kit.ToError(fmt.Sprintf("Failed to retrieve metric '%s' from AppDynamics.", state.MetricPath), err))
+		}
+		log.Warn().Err(err).Msgf("Failed to retrieve metric '%s' (%d of %d tolerated failed polls).", state.MetricPath, state.ConsecutiveFailedPolls, state.FailedPollTolerance)
+		return state.statusResult(completed, nil, fmt.Sprintf("%s unknown: %s", state.MetricLabel, err.Error()), now), nil
+	}
+	state.ConsecutiveFailedPolls = 0
+
+	value, ok := getLatestMetricValue(metricData)
+	if !ok {
+		return state.statusResult(completed, nil, fmt.Sprintf("%s unknown: no value reported within the last %s", state.MetricLabel, metricCheckWindow), now), nil
+	}
+	return state.statusResult(completed, &value, fmt.Sprintf("%s: %d (expected %s %d)", state.MetricLabel, value, state.Expectation, state.Threshold), now), nil
+}
+
+// statusResult evaluates the value, if known, and returns the result of the poll.
+func (s *MetricCheckState) statusResult(completed bool, value *int64, tooltip string, now time.Time) *action_kit_api.StatusResult {
+	var checkError *action_kit_api.ActionKitError
+	metricState := "unknown"
+	var metricValue float64
+
+	if value != nil {
+		metricValue = float64(*value)
+		expected := s.isExpected(*value)
+		metricState = "success"
+		if !expected {
+			metricState = "danger"
+		}
+
+		if s.StateCheckMode == StateCheckModeAllTheTime && !expected {
+			if s.FailEarly {
+				checkError = new(action_kit_api.ActionKitError{
+					Title:  fmt.Sprintf("%s of '%s' is %d whereas it is expected to be %s %d.", s.MetricLabel, s.TargetName, *value, s.Expectation, s.Threshold),
+					Status: extutil.Ptr(action_kit_api.Failed),
+				})
+			} else {
+				s.DeviationSeen = true
+				s.DeviationTitle = fmt.Sprintf("%s of '%s' was %d whereas it is expected to be %s %d.", s.MetricLabel, s.TargetName, *value, s.Expectation, s.Threshold)
+			}
+		} else if s.StateCheckMode == StateCheckModeAtLeastOnce && expected {
+			s.StateCheckSuccess = true
+		}
+	}
+
+	if completed && checkError == nil {
+		if s.StateCheckMode == StateCheckModeAllTheTime && !s.FailEarly && s.DeviationSeen {
+			checkError = new(action_kit_api.ActionKitError{
+				Title:  s.DeviationTitle,
+				Status: extutil.Ptr(action_kit_api.Failed),
+			})
+		} else if s.StateCheckMode == StateCheckModeAtLeastOnce && !s.StateCheckSuccess {
+			checkError = new(action_kit_api.ActionKitError{
+				Title:  fmt.Sprintf("%s of '%s' was never %s %d as expected once.", s.MetricLabel, s.TargetName, s.Expectation, s.Threshold),
+				Status: extutil.Ptr(action_kit_api.Failed),
+			})
+		}
+	}
+
+	return &action_kit_api.StatusResult{
+		Completed: completed,
+		Error:     checkError,
+		Metrics: new([]action_kit_api.Metric{{
+			Name: new("appdynamics_metric_state"),
+			Metric: map[string]string{
+				s.TargetAttribute + ".id":   s.TargetId,
+				s.TargetAttribute + ".name": s.TargetName,
+				"metric":                    s.MetricLabel,
+				"state":                     metricState,
+				"tooltip":                   tooltip,
+			},
+			Timestamp: now,
+			Value:     metricValue,
+		}}),
+	}
+}
+
+func (s *MetricCheckState) isExpected(value int64) bool {
+	if s.Expectation == MetricExpectationBelow {
+		return value < s.Threshold
+	}
+	return value > s.Threshold
+}
+
+// getLatestMetricValue returns the most recent value of the metric data. Wildcard paths can match several metrics, e.g.
+// the wait states of a database, their values of the most recent minute are summed up.
+func getLatestMetricValue(metricData []appdclient.MetricData) (int64, bool) {
+	latest := int64(-1)
+	var sum int64
+	for _, data := range metricData {
+		for _, value := range data.MetricValues {
+			if value.StartTimeInMillis > latest {
+				latest = value.StartTimeInMillis
+				sum = value.Value
+			} else if value.StartTimeInMillis == latest {
+				sum += value.Value
+			}
+		}
+	}
+	if latest < 0 {
+		return 0, false
+	}
+	return sum, true
+}
@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetricDataClient(t *testing.T, status int, body string) *appdclient.Client {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(ts.Close)
+	return appdclient.New(resty.New().SetBaseURL(ts.URL))
+}
+
+func newMetricCheckState(mode string, end time.Time) *MetricCheckState {
+	return &MetricCheckState{
+		Controller:      "default",
+		ApplicationId:   simApplication,
+		MetricPath:      "Application Infrastructure Performance|Root|Individual Nodes|web-1|Hardware Resources|CPU|%Busy",
+		MetricLabel:     "CPU busy %",
+		TargetAttribute: MachineAttribute,
+		TargetId:        "1",
+		TargetName:      "web-1",
+		End:             end,
+		Expectation:     MetricExpectationAbove,
+		Threshold:       80,
+		StateCheckMode:  mode,
+	}
+}
+
+const cpuMetricData = `[{"metricPath":"CPU|%Busy","metricValues":[{"startTimeInMillis":1000,"value":95},{"startTimeInMillis":2000,"value":42}]}]`
+
+func TestMetricCheckAllTheTimeFailsOnDeviation(t *testing.T) {
+	client := newMetricDataClient(t, http.StatusOK, cpuMetricData)
+	state := newMetricCheckState(StateCheckModeAllTheTime, time.Now().Add(time.Minute))
+
+	result, err := MetricCheckStatus(context.Background(), state, client)
+	require.NoError(t, err)
+	assert.False(t, result.Completed)
+	assert.Nil(t, result.Error)
+	assert.True(t, state.DeviationSeen)
+	assert.Equal(t, "danger", (*result.Metrics)[0].Metric["state"])
+	assert.Equal(t, float64(42), (*result.Metrics)[0].Value)
+
+	state.End = time.Now().Add(-time.Second)
+	result, err = MetricCheckStatus(context.Background(), state, client)
+	require.NoError(t, err)
+	assert.True(t, result.Completed)
+	require.NotNil(t, result.Error)
+	assert.Contains(t, result.Error.Title, "was 42 whereas it is expected to be above 80")
+}
+
+func TestMetricCheckAtLeastOnceSucceeds(t *testing.T) {
+	client := newMetricDataClient(t, http.StatusOK, cpuMetricData)
+	state := newMetricCheckState(StateCheckModeAtLeastOnce, time.Now().Add(-time.Second))
+	state.Expectation = MetricExpectationBelow
+
+	result, err := MetricCheckStatus(context.Background(), state, client)
+	require.NoError(t, err)
+	assert.True(t, result.Completed)
+	assert.Nil(t, result.Error)
+	assert.Equal(t, "success", (*result.Metrics)[0].Metric["state"])
+}
+
+func TestMetricCheckWithoutValueIsUnknown(t *testing.T) {
+	client := newMetricDataClient(t, http.StatusOK, `[{"metricPath":"CPU|%Busy","metricValues":[]}]`)
+	state := newMetricCheckState(StateCheckModeAtLeastOnce, time.Now().Add(-time.Second))
+
+	result, err := MetricCheckStatus(context.Background(), state, client)
+	require.NoError(t, err)
+	assert.Equal(t, "unknown", (*result.Metrics)[0].Metric["state"])
+	require.NotNil(t, result.Error)
+	assert.Contains(t, result.Error.Title, "was never above 80")
+}
+
+func TestMetricCheckToleratesFailedPolls(t *testing.T) {
+	client := newMetricDataClient(t, http.StatusInternalServerError, `{}`)
+	state := newMetricCheckState(StateCheckModeAllTheTime, time.Now().Add(time.Minute))
+	state.FailedPollTolerance = 1
+
+	result, err := MetricCheckStatus(context.Background(), state, client)
+	require.NoError(t, err)
+	assert.Equal(t, "unknown", (*result.Metrics)[0].Metric["state"])
+
+	_, err = MetricCheckStatus(context.Background(), state, client)
+	assert.Error(t, err)
+}
+
+func TestMetricCheckSumsWildcardMetrics(t *testing.T) {
+	client := newMetricDataClient(t, http.StatusOK, `[
+		{"metricPath":"Databases|orders-db|Wait States|CPU","metricValues":[{"startTimeInMillis":1000,"value":50},{"startTimeInMillis":2000,"value":4}]},
+		{"metricPath":"Databases|orders-db|Wait States|Lock","metricValues":[{"startTimeInMillis":2000,"value":7}]}
+	]`)
+	state := newMetricCheckState(StateCheckModeAtLeastOnce, time.Now().Add(-time.Second))
+	state.Threshold = 10
+
+	result, err := MetricCheckStatus(context.Background(), state, client)
+	require.NoError(t, err)
+	assert.Nil(t, result.Error)
+	assert.Equal(t, float64(11), (*result.Metrics)[0].Value)
+}
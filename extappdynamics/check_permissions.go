@@ -0,0 +1,315 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/steadybit/extension-appdynamics/config"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// PermissionGranted means the controller accepted the request.
+	PermissionGranted = "granted"
+	// PermissionDenied means the controller rejected the request as unauthorized or forbidden.
+	PermissionDenied = "denied"
+	// PermissionFailed means the request failed for another reason, so the permission is unknown.
+	PermissionFailed = "failed"
+	// PermissionSkipped means the request wasn't sent, e.g. in dry run or because a prerequisite failed.
+	PermissionSkipped = "skipped"
+)
+
+type PermissionCheckAction struct {
+	controllers Controllers
+}
+
+// Make sure action implements all required interfaces
+var (
+	_ action_kit_sdk.Action[PermissionCheckState] = (*PermissionCheckAction)(nil)
+)
+
+type PermissionCheckState struct {
+	ApplicationId string
+	Controller    string
+	DryRun        bool
+	Trace         ExecutionTrace
+}
+
+func (s *PermissionCheckState) spanAttributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("appdynamics.controller", s.Controller),
+		attribute.String("appdynamics.application.id", s.ApplicationId),
+		attribute.Bool("appdynamics.dry-run", s.DryRun),
+	}
+}
+
+// PermissionCheckResult is a row of the permission matrix reported by the permission check.
+type PermissionCheckResult struct {
+	Permission string `json:"permission"`
+	Request    string `json:"request"`
+	Outcome    string `json:"outcome"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func NewPermissionCheckAction(controllers Controllers) action_kit_sdk.Action[PermissionCheckState] {
+	return &PermissionCheckAction{controllers: controllers}
+}
+
+func (m *PermissionCheckAction) NewEmptyState() PermissionCheckState {
+	return PermissionCheckState{}
+}
+
+func (m *PermissionCheckAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.permission-check", applicationTargetType),
+		Label:       "Permission Check",
+		Description: "Verify that the API client has all permissions the extension needs for an application and report them as permission matrix.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(appDynamicsTargetIcon),
+		Technology:  new("AppDynamics"),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType:          applicationTargetType,
+			QuantityRestriction: extutil.Ptr(action_kit_api.QuantityRestrictionAll),
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label: "by application name",
+					Query: "appdynamics.application.name=\"\"",
+				},
+			}),
+		}),
+		Kind:        action_kit_api.Check,
+		TimeControl: action_kit_api.TimeControlInstantaneous,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "dryRun",
+				Label:        "Dry run",
+				Description:  new("Only check the permissions to read from the controller and skip creating and deleting an action suppression and posting an event. Always enabled if the extension runs in dry-run mode."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("false"),
+				Advanced:     new(true),
+				Order:        new(1),
+				Required:     new(false),
+			},
+		},
+	}
+}
+
+func (m *PermissionCheckAction) Prepare(ctx context.Context, state *PermissionCheckState, request action_kit_api.PrepareActionRequestBody) (_ *action_kit_api.PrepareResult, err error) {
+	state.Trace = newExecutionTrace(request)
+	_, span := startActionSpan(ctx, "permission-check.prepare", state.Trace)
+	defer func() { endActionSpan(span, nil, err) }()
+
+	applicationID := request.Target.Attributes["appdynamics.application.id"]
+	if len(applicationID) == 0 {
+		return nil, extension_kit.ToError("Target is missing the 'appdynamics.application.id' tag.", nil)
+	}
+
+	controller, err := m.controllers.get(getControllerName(request.Target.Attributes, AppAttribute+AppController))
+	if err != nil {
+		return nil, extension_kit.ToError("Failed to resolve the AppDynamics controller of the target.", err)
+	}
+
+	state.ApplicationId = applicationID[0]
+	state.Controller = controller.Name
+	state.DryRun = isDryRun(request.Config)
+	span.SetAttributes(state.spanAttributes()...)
+
+	return nil, nil
+}
+
+func (m *PermissionCheckAction) Start(ctx context.Context, state *PermissionCheckState) (result *action_kit_api.StartResult, err error) {
+	ctx, span := startActionSpan(ctx, "permission-check.start", state.Trace, state.spanAttributes()...)
+	defer func() {
+		var actionError *action_kit_api.ActionKitError
+		if result != nil {
+			actionError = result.Error
+		}
+		endActionSpan(span, actionError, err)
+	}()
+	ctx = appdclient.WithAuditInitiator(ctx, state.Trace.auditInitiator())
+
+	controller, err := m.controllers.get(state.Controller)
+	if err != nil {
+		return nil, extension_kit.ToError("Failed to resolve the AppDynamics controller of the target.", err)
+	}
+	eventController, err := m.controllers.get("")
+	if err != nil {
+		return nil, extension_kit.ToError("Failed to resolve the default AppDynamics controller.", err)
+	}
+	return PermissionCheckStart(ctx, state, controller.Client, eventController)
+}
+
+// PermissionCheckStart calls every API of the controller the extension uses for the application. Custom events are
+// posted through the eventController, the default controller. It fails if any of the calls was denied or failed, the
+// permission matrix is attached as artifact either way.
+func PermissionCheckStart(ctx context.Context, state *PermissionCheckState, client AppDynamicsClient, eventController *Controller) (*action_kit_api.StartResult, error) {
+	results := checkPermissions(ctx, state, client, eventController)
+
+	artifact, err := newJsonArtifact("permissions.json", results)
+	if err != nil {
+		return nil, extension_kit.ToError("Failed to encode the permission matrix.", err)
+	}
+
+	messages := make(action_kit_api.Messages, 0, len(results))
+	for _, result := range results {
+		level := action_kit_api.Info
+		message := fmt.Sprintf("%s (%s): %s", result.Permission, result.Request, result.Outcome)
+		if result.Outcome == PermissionDenied || result.Outcome == PermissionFailed {
+			level = action_kit_api.Warn
+			message += " - " + result.Error
+		}
+		messages = append(messages, action_kit_api.Message{Level: extutil.Ptr(level), Message: message})
+	}
+
+	startResult := &action_kit_api.StartResult{
+		Messages:  &messages,
+		Artifacts: &action_kit_api.Artifacts{artifact},
+	}
+	if missing := getMissingPermissions(results); len(missing) > 0 {
+		startResult.Error = new(action_kit_api.ActionKitError{
+			Title:  fmt.Sprintf("The API client of controller '%s' lacks permissions for Application ID %s: %s.", state.Controller, state.ApplicationId, strings.Join(missing, ", ")),
+			Status: extutil.Ptr(action_kit_api.Failed),
+		})
+	}
+	return startResult, nil
+}
+
+func checkPermissions(ctx context.Context, state *PermissionCheckState, client AppDynamicsClient, eventController *Controller) []PermissionCheckResult {
+	applicationUri := "/controller/rest/applications/" + state.ApplicationId
+	alertingUri := "/controller/alerting/rest/v1/applications/" + state.ApplicationId
+	results := make([]PermissionCheckResult, 0, 6)
+
+	// Custom events are posted through the default controller to the configured event application, which may differ
+	// from the checked application.
+	eventPermission := "Create events"
+	eventUri := applicationUri + "/events"
+	eventApplicationId := state.ApplicationId
+	if config.Config.EventApplicationID != "" {
+		eventApplicationId = config.Config.EventApplicationID
+		eventPermission = fmt.Sprintf("Create events in Application ID %s", eventApplicationId)
+		eventUri = "/controller/rest/applications/" + eventApplicationId + "/events"
+	}
+	if eventController.Name != state.Controller {
+		eventPermission += fmt.Sprintf(" on controller '%s'", eventController.Name)
+	}
+
+	applications, err := client.ListApplications(ctx)
+	if err == nil && !slices.ContainsFunc(applications, func(application appdclient.Application) bool {
+		return strconv.Itoa(application.ID) == state.ApplicationId
+	}) {
+		results = append(results, PermissionCheckResult{Permission: "View application", Request: "GET /controller/rest/applications", Outcome: PermissionDenied, Error: "the application is not visible to the API client"})
+	} else {
+		results = append(results, newPermissionCheckResult("View application", "GET /controller/rest/applications", err))
+	}
+
+	_, err = client.ListHealthRules(ctx, state.ApplicationId)
+	results = append(results, newPermissionCheckResult("View health rules", "GET "+alertingUri+"/health-rules", err))
+
+	now := time.Now()
+	_, err = client.GetViolations(ctx, state.ApplicationId, now.Add(-5*time.Minute), now)
+	results = append(results, newPermissionCheckResult("View health rule violations", "GET "+applicationUri+"/problems/healthrule-violations", err))
+
+	if state.DryRun {
+		return append(results,
+			PermissionCheckResult{Permission: "Create action suppressions", Request: "POST " + alertingUri + "/action-suppressions", Outcome: PermissionSkipped, Error: "dry run"},
+			PermissionCheckResult{Permission: "Delete action suppressions", Request: "DELETE " + alertingUri + "/action-suppressions/{id}", Outcome: PermissionSkipped, Error: "dry run"},
+			PermissionCheckResult{Permission: eventPermission, Request: "POST " + eventUri, Outcome: PermissionSkipped, Error: "dry run"},
+		)
+	}
+
+	results = append(results, checkActionSuppressionPermissions(ctx, state.ApplicationId, client)...)
+
+	if eventController.Name != state.Controller && config.Config.EventApplicationID == "" {
+		// The checked application is unknown to the default controller, so there is no application to post to.
+		return append(results, PermissionCheckResult{Permission: eventPermission, Request: "POST " + eventUri, Outcome: PermissionSkipped, Error: "no event application is configured"})
+	}
+	query := url.Values{
+		"eventtype":       {"CUSTOM"},
+		"customeventtype": {"Steadybit"},
+		"severity":        {"INFO"},
+		"summary":         {"Steadybit permission check"},
+	}.Encode()
+	_, err = eventController.Client.PostEvent(ctx, eventApplicationId, query)
+	results = append(results, newPermissionCheckResult(eventPermission, "POST "+eventUri, err))
+
+	return results
+}
+
+// checkActionSuppressionPermissions creates and deletes an action suppression that starts and ends now to validate the
+// permissions to create and delete action suppressions.
+func checkActionSuppressionPermissions(ctx context.Context, applicationId string, client AppDynamicsClient) []PermissionCheckResult {
+	alertingUri := "/controller/alerting/rest/v1/applications/" + applicationId
+	actionSuppression, err := createZeroLengthActionSuppression(ctx, applicationId, client)
+	results := []PermissionCheckResult{newPermissionCheckResult("Create action suppressions", "POST "+alertingUri+"/action-suppressions", err)}
+	if err != nil {
+		return append(results, PermissionCheckResult{Permission: "Delete action suppressions", Request: "DELETE " + alertingUri + "/action-suppressions/{id}", Outcome: PermissionSkipped, Error: "no action suppression was created"})
+	}
+	actionSuppressionId := strconv.Itoa(actionSuppression.ID)
+	err = client.DeleteActionSuppression(ctx, applicationId, actionSuppressionId)
+	return append(results, newPermissionCheckResult("Delete action suppressions", "DELETE "+alertingUri+"/action-suppressions/"+actionSuppressionId, err))
+}
+
+// getMissingPermissions returns the permissions that were denied or failed.
+func getMissingPermissions(results []PermissionCheckResult) []string {
+	var missing []string
+	for _, result := range results {
+		if result.Outcome == PermissionDenied || result.Outcome == PermissionFailed {
+			missing = append(missing, result.Permission)
+		}
+	}
+	return missing
+}
+
+// createZeroLengthActionSuppression creates an action suppression that starts and ends now, so it never suppresses
+// anything even if it can't be deleted again.
+func createZeroLengthActionSuppression(ctx context.Context, applicationId string, client AppDynamicsClient) (*appdclient.ActionSuppressionResponse, error) {
+	timezone, err := getActionSuppressionTimezone()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Format(time.RFC3339)
+	return client.CreateActionSuppression(ctx, applicationId, appdclient.ActionSuppressionRequest{
+		Name:                    "Steadybit-permission-check-" + uuid.New().String(),
+		Affects:                 appdclient.Affects{AffectedInfoType: "APPLICATION"},
+		StartTime:               now,
+		EndTime:                 now,
+		SuppressionScheduleType: "ONE_TIME",
+		Timezone:                timezone,
+	})
+}
+
+func newPermissionCheckResult(permission string, request string, err error) PermissionCheckResult {
+	result := PermissionCheckResult{Permission: permission, Request: request, Outcome: PermissionGranted}
+	if err == nil {
+		return result
+	}
+
+	result.Outcome = PermissionFailed
+	result.Error = err.Error()
+	var statusErr *appdclient.StatusError
+	if errors.As(err, &statusErr) {
+		result.StatusCode = statusErr.StatusCode
+		if statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden {
+			result.Outcome = PermissionDenied
+		}
+	}
+	return result
+}
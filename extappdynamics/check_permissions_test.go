@@ -0,0 +1,195 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/steadybit/extension-appdynamics/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPermissionCheckServer(t *testing.T, handle func(w http.ResponseWriter, r *http.Request) bool) *appdclient.Client {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handle != nil && handle(w, r) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/controller/rest/applications":
+			_, _ = w.Write([]byte(`[{"id":42,"name":"checkout"}]`))
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/action-suppressions"):
+			_, _ = w.Write([]byte(`{"id":7}`))
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/events"):
+			_, _ = w.Write([]byte(`Successfully created the event id: 1`))
+		case r.Method == http.MethodDelete:
+		default:
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return appdclient.New(resty.New().SetBaseURL(ts.URL))
+}
+
+func getPermissionMatrix(t *testing.T, artifacts *action_kit_api.Artifacts) map[string]PermissionCheckResult {
+	require.NotNil(t, artifacts)
+	require.Len(t, *artifacts, 1)
+
+	matrix, err := base64.StdEncoding.DecodeString((*artifacts)[0].Data)
+	require.NoError(t, err)
+	var results []PermissionCheckResult
+	require.NoError(t, json.Unmarshal(matrix, &results))
+
+	byPermission := make(map[string]PermissionCheckResult, len(results))
+	for _, result := range results {
+		byPermission[result.Permission] = result
+	}
+	return byPermission
+}
+
+func TestPermissionCheckGranted(t *testing.T) {
+	var deleted string
+	client := newPermissionCheckServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == http.MethodDelete {
+			deleted = r.URL.Path
+		}
+		return false
+	})
+
+	result, err := PermissionCheckStart(context.Background(), &PermissionCheckState{ApplicationId: "42", Controller: "default"}, client, &Controller{Name: "default", Client: client})
+	require.NoError(t, err)
+	assert.Nil(t, result.Error)
+	assert.Equal(t, "/controller/alerting/rest/v1/applications/42/action-suppressions/7", deleted)
+
+	matrix := getPermissionMatrix(t, result.Artifacts)
+	assert.Len(t, matrix, 6)
+	for permission, row := range matrix {
+		assert.Equal(t, PermissionGranted, row.Outcome, permission)
+	}
+}
+
+func TestPermissionCheckReportsMissingPermissions(t *testing.T) {
+	client := newPermissionCheckServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/action-suppressions") {
+			w.WriteHeader(http.StatusForbidden)
+			return true
+		}
+		if strings.HasSuffix(r.URL.Path, "/health-rules") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return true
+		}
+		return false
+	})
+
+	result, err := PermissionCheckStart(context.Background(), &PermissionCheckState{ApplicationId: "42", Controller: "default"}, client, &Controller{Name: "default", Client: client})
+	require.NoError(t, err)
+	require.NotNil(t, result.Error)
+	assert.Contains(t, result.Error.Title, "Create action suppressions")
+	assert.Contains(t, result.Error.Title, "View health rules")
+
+	matrix := getPermissionMatrix(t, result.Artifacts)
+	assert.Equal(t, PermissionDenied, matrix["Create action suppressions"].Outcome)
+	assert.Equal(t, http.StatusForbidden, matrix["Create action suppressions"].StatusCode)
+	assert.Equal(t, PermissionSkipped, matrix["Delete action suppressions"].Outcome)
+	assert.Equal(t, PermissionFailed, matrix["View health rules"].Outcome)
+	assert.Equal(t, PermissionGranted, matrix["Create events"].Outcome)
+}
+
+func TestPermissionCheckDeniesInvisibleApplication(t *testing.T) {
+	client := newPermissionCheckServer(t, nil)
+
+	result, err := PermissionCheckStart(context.Background(), &PermissionCheckState{ApplicationId: "43", Controller: "default"}, client, &Controller{Name: "default", Client: client})
+	require.NoError(t, err)
+	require.NotNil(t, result.Error)
+	assert.Equal(t, PermissionDenied, getPermissionMatrix(t, result.Artifacts)["View application"].Outcome)
+}
+
+func TestPermissionCheckDryRunOnlyReads(t *testing.T) {
+	client := newPermissionCheckServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method != http.MethodGet {
+			t.Errorf("expected only GET in dry run, got %s %s", r.Method, r.URL.Path)
+		}
+		return false
+	})
+
+	result, err := PermissionCheckStart(context.Background(), &PermissionCheckState{ApplicationId: "42", Controller: "default", DryRun: true}, client, &Controller{Name: "default", Client: client})
+	require.NoError(t, err)
+	assert.Nil(t, result.Error)
+
+	matrix := getPermissionMatrix(t, result.Artifacts)
+	assert.Equal(t, PermissionGranted, matrix["View health rule violations"].Outcome)
+	assert.Equal(t, PermissionSkipped, matrix["Create action suppressions"].Outcome)
+	assert.Equal(t, PermissionSkipped, matrix["Create events"].Outcome)
+}
+
+func TestPermissionCheckPostsEventsToEventApplication(t *testing.T) {
+	old := config.Config
+	t.Cleanup(func() { config.Config = old })
+	config.Config.EventApplicationID = "99"
+
+	var eventPaths []string
+	client := newPermissionCheckServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/events") {
+			eventPaths = append(eventPaths, r.URL.Path)
+		}
+		return false
+	})
+
+	result, err := PermissionCheckStart(context.Background(), &PermissionCheckState{ApplicationId: "42", Controller: "default"}, client, &Controller{Name: "default", Client: client})
+	require.NoError(t, err)
+	assert.Nil(t, result.Error)
+
+	assert.Equal(t, []string{"/controller/rest/applications/99/events"}, eventPaths)
+	event := getPermissionMatrix(t, result.Artifacts)["Create events in Application ID 99"]
+	assert.Equal(t, PermissionGranted, event.Outcome)
+	assert.Equal(t, "POST /controller/rest/applications/99/events", event.Request)
+}
+
+func TestPermissionCheckPostsEventsThroughDefaultController(t *testing.T) {
+	old := config.Config
+	t.Cleanup(func() { config.Config = old })
+	config.Config.EventApplicationID = "99"
+
+	client := newPermissionCheckServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/events") {
+			t.Errorf("expected no event to be posted to the controller of the target, got %s", r.URL.Path)
+		}
+		return false
+	})
+	var eventPaths []string
+	defaultClient := newPermissionCheckServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/events") {
+			eventPaths = append(eventPaths, r.URL.Path)
+			return false
+		}
+		t.Errorf("expected only events to be posted to the default controller, got %s %s", r.Method, r.URL.Path)
+		return false
+	})
+
+	result, err := PermissionCheckStart(context.Background(), &PermissionCheckState{ApplicationId: "42", Controller: "eu"}, client, &Controller{Name: "default", Client: defaultClient})
+	require.NoError(t, err)
+	assert.Nil(t, result.Error)
+
+	assert.Equal(t, []string{"/controller/rest/applications/99/events"}, eventPaths)
+	assert.Equal(t, PermissionGranted, getPermissionMatrix(t, result.Artifacts)["Create events in Application ID 99 on controller 'default'"].Outcome)
+
+	config.Config.EventApplicationID = ""
+	eventPaths = nil
+	result, err = PermissionCheckStart(context.Background(), &PermissionCheckState{ApplicationId: "42", Controller: "eu"}, client, &Controller{Name: "default", Client: defaultClient})
+	require.NoError(t, err)
+	assert.Nil(t, result.Error)
+
+	assert.Empty(t, eventPaths)
+	assert.Equal(t, PermissionSkipped, getPermissionMatrix(t, result.Artifacts)["Create events on controller 'default'"].Outcome)
+}
@@ -7,13 +7,23 @@
 
 package extappdynamics
 
-import "github.com/go-resty/resty/v2"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
 
-var RestyClient *resty.Client
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-appdynamics/config"
+	"github.com/steadybit/extension-kit/extutil"
+)
 
 const (
 	applicationTargetType           = "com.steadybit.extension_appdynamics.application"
 	applicationHealthRuleTargetType = "com.steadybit.extension_appdynamics.health-rule"
+	machineTargetType               = "com.steadybit.extension_appdynamics.machine"
+	databaseTargetType              = "com.steadybit.extension_appdynamics.database"
+	backendTargetType               = "com.steadybit.extension_appdynamics.backend"
 	appDynamicsTargetIcon           = "data:image/svg+xml;base64,PHN2ZyB3aWR0aD0iMjQiIGhlaWdodD0iMjQiIHZpZXdCb3g9IjAgMCAyNCAyNCIgeG1sbnM9Imh0dHA6Ly93d3cudzMub3JnLzIwMDAvc3ZnIj4KPHBhdGggZD0iTTkuNDkyMzcgMS41QzE1Ljg3NjkgMS41IDIxLjA1MTcgNi42NzQwOSAyMS4wMjE3IDEzLjA1ODZDMjEuMDIxNyAxNi45NjIxIDE5LjA4NDcgMjAuNDEyMSAxNi4xMTkzIDIyLjVMMTQuMzAzOSAxOC42ODc1QzE1LjkwNzYgMTcuMzI1OCAxNi45MDY0IDE1LjI5NzggMTYuOTA2NCAxMy4wNTg2QzE2LjkwNjIgOC45NzM4IDEzLjU3NzIgNS42NDU1MSA5LjQ5MjM3IDUuNjQ1NTFDOS4wMzg1OSA1LjY0NTUyIDguNTg0ODIgNS42NzU4NSA4LjEzMTA0IDUuNzY2Nkw2LjMxNTYxIDEuOTU0MUM3LjMxNDA1IDEuNjUxNTUgOC40MDMxNyAxLjUwMDAzIDkuNDkyMzcgMS41Wk0xMC42NDI4IDIwLjM4MThDMTAuMjQ5NCAyMC40NDI0IDkuODg1NzQgMjAuNDcyNyA5LjQ5MjM3IDIwLjQ3MjdDNS40MDc1IDIwLjQ3MjUgMi4wNzkyOCAxNy4xNDM1IDIuMDc5MjggMTMuMDU4NkMyLjA3OTQxIDEwLjg4MDEgMy4wMTc1NyA4Ljk0MzYxIDQuNTAwMTggNy41ODIwM0wxMC42NDI4IDIwLjM4MThaIiBmaWxsPSJjdXJyZW50Q29sb3IiLz4KPC9zdmc+"
 	StateCheckModeAtLeastOnce       = "atLeastOnce"
 	StateCheckModeAllTheTime        = "allTheTime"
@@ -21,3 +31,40 @@ const (
 	violationsExpected   = "true"
 	noViolationsExpected = "false"
 )
+
+// getDiscoveryInterval returns the configured refresh interval of a discovery, or one minute if none is configured.
+func getDiscoveryInterval(configured time.Duration) time.Duration {
+	if configured > 0 {
+		return configured
+	}
+	return 1 * time.Minute
+}
+
+// getCallInterval returns the interval in which the platform should call a discovery. Calling more often than the
+// discovery refreshes its targets would only return cached targets.
+func getCallInterval(defaultCallInterval time.Duration, refreshInterval time.Duration) string {
+	interval := max(defaultCallInterval, refreshInterval)
+	switch {
+	case interval%time.Hour == 0:
+		return fmt.Sprintf("%dh", interval/time.Hour)
+	case interval%time.Minute == 0:
+		return fmt.Sprintf("%dm", interval/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", max(interval/time.Second, 1))
+	}
+}
+
+// isDryRun reports whether a mutating action should only validate its permissions and build its requests instead of
+// sending them, either because the extension runs in dry-run mode or because of the dryRun parameter of the action.
+func isDryRun(actionConfig map[string]any) bool {
+	return config.Config.DryRun || extutil.ToBool(actionConfig["dryRun"])
+}
+
+// newJsonArtifact returns the payload, e.g. the request a mutating action would have sent in dry run, as JSON artifact.
+func newJsonArtifact(label string, payload any) (action_kit_api.Artifact, error) {
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return action_kit_api.Artifact{}, err
+	}
+	return action_kit_api.Artifact{Label: label, Data: base64.StdEncoding.EncodeToString(data)}, nil
+}
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/extension-kit/exthealth"
+)
+
+const (
+	// ReadinessAll requires all controllers to be reachable for the extension to be ready.
+	ReadinessAll = "all"
+	// ReadinessAny keeps the extension ready in a degraded mode as long as at least one controller is reachable.
+	ReadinessAny = "any"
+	// ReadinessIgnore keeps the extension ready regardless of the connectivity to the controllers.
+	ReadinessIgnore = "ignore"
+)
+
+const connectivityProbeTimeout = 30 * time.Second
+
+// ControllerStatus is the result of the latest connectivity probes of a controller.
+type ControllerStatus struct {
+	Name          string     `json:"name"`
+	Url           string     `json:"url"`
+	Reachable     bool       `json:"reachable"`
+	LastSuccess   *time.Time `json:"lastSuccess,omitempty"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastErrorTime *time.Time `json:"lastErrorTime,omitempty"`
+	TokenExpiry   *time.Time `json:"tokenExpiry,omitempty"`
+}
+
+type Diagnostics struct {
+	Ready       bool               `json:"ready"`
+	Degraded    bool               `json:"degraded"`
+	Controllers []ControllerStatus `json:"controllers"`
+}
+
+// ConnectivityProbe periodically fetches a token from and calls each controller. The results determine the readiness
+// of the extension and are served as diagnostics.
+type ConnectivityProbe struct {
+	controllers Controllers
+	readiness   string
+
+	mutex    sync.RWMutex
+	statuses map[string]*ControllerStatus
+}
+
+func NewConnectivityProbe(controllers Controllers, readiness string) *ConnectivityProbe {
+	statuses := make(map[string]*ControllerStatus, len(controllers))
+	for _, controller := range controllers {
+		statuses[controller.Name] = &ControllerStatus{Name: controller.Name, Url: controller.Client.BaseUrl()}
+	}
+	return &ConnectivityProbe{controllers: controllers, readiness: readiness, statuses: statuses}
+}
+
+// Start probes all controllers in the background and keeps probing in the given interval. Unless the connectivity is
+// ignored, the extension becomes ready once the first probe succeeded.
+func (p *ConnectivityProbe) Start(interval time.Duration) {
+	if p.readiness == ReadinessIgnore {
+		exthealth.SetReady(true)
+	}
+	go func() {
+		p.Probe(context.Background())
+		if interval <= 0 {
+			return
+		}
+		for range time.Tick(interval) {
+			p.Probe(context.Background())
+		}
+	}()
+}
+
+// Probe checks the connectivity to all controllers and updates the readiness.
+func (p *ConnectivityProbe) Probe(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, controller := range p.controllers {
+		wg.Go(func() {
+			p.probe(ctx, controller)
+		})
+	}
+	wg.Wait()
+	exthealth.SetReady(p.GetDiagnostics().Ready)
+}
+
+func (p *ConnectivityProbe) probe(ctx context.Context, controller *Controller) {
+	ctx, cancel := context.WithTimeout(ctx, connectivityProbeTimeout)
+	defer cancel()
+
+	// Listing the applications requires a valid token and the permissions the discoveries need anyway.
+	_, err := controller.Client.ListApplications(ctx)
+	now := time.Now()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	status := p.statuses[controller.Name]
+	if err != nil {
+		if status.Reachable || status.LastErrorTime == nil {
+			log.Warn().Err(err).Msgf("AppDynamics controller '%s' is not reachable.", controller.Name)
+		}
+		status.Reachable = false
+		status.LastError = err.Error()
+		status.LastErrorTime = &now
+	} else {
+		if !status.Reachable && status.LastErrorTime != nil {
+			log.Info().Msgf("AppDynamics controller '%s' is reachable again.", controller.Name)
+		}
+		status.Reachable = true
+		status.LastSuccess = &now
+	}
+	if controller.Credentials != nil {
+		if expiry := controller.Credentials.TokenExpiry(); !expiry.IsZero() {
+			status.TokenExpiry = &expiry
+		}
+	}
+}
+
+func (p *ConnectivityProbe) GetDiagnostics() Diagnostics {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	diagnostics := Diagnostics{Controllers: make([]ControllerStatus, 0, len(p.controllers))}
+	reachable := 0
+	for _, controller := range p.controllers {
+		status := *p.statuses[controller.Name]
+		if status.Reachable {
+			reachable++
+		}
+		diagnostics.Controllers = append(diagnostics.Controllers, status)
+	}
+
+	diagnostics.Degraded = reachable < len(p.controllers)
+	switch p.readiness {
+	case ReadinessIgnore:
+		diagnostics.Ready = true
+	case ReadinessAny:
+		diagnostics.Ready = reachable > 0
+	default:
+		diagnostics.Ready = !diagnostics.Degraded
+	}
+	return diagnostics
+}
+
+// ServeHTTP serves the diagnostics as JSON.
+func (p *ConnectivityProbe) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(p.GetDiagnostics()); err != nil {
+		log.Warn().Err(err).Msg("Failed to write diagnostics.")
+	}
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/stretchr/testify/require"
+)
+
+func newProbeTestController(t *testing.T, name string, status int) *Controller {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(ts.Close)
+	return &Controller{Name: name, Client: appdclient.New(resty.New().SetBaseURL(ts.URL))}
+}
+
+func TestConnectivityProbe_Readiness(t *testing.T) {
+	controllers := Controllers{
+		newProbeTestController(t, "default", http.StatusOK),
+		newProbeTestController(t, "onprem", http.StatusUnauthorized),
+	}
+
+	tests := []struct {
+		readiness string
+		ready     bool
+	}{
+		{ReadinessAll, false},
+		{ReadinessAny, true},
+		{ReadinessIgnore, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.readiness, func(t *testing.T) {
+			probe := NewConnectivityProbe(controllers, tt.readiness)
+			probe.Probe(context.Background())
+
+			diagnostics := probe.GetDiagnostics()
+			require.Equal(t, tt.ready, diagnostics.Ready)
+			require.True(t, diagnostics.Degraded)
+		})
+	}
+}
+
+func TestConnectivityProbe_Diagnostics(t *testing.T) {
+	probe := NewConnectivityProbe(Controllers{
+		newProbeTestController(t, "default", http.StatusOK),
+		newProbeTestController(t, "onprem", http.StatusUnauthorized),
+	}, ReadinessAny)
+
+	rec := httptest.NewRecorder()
+	probe.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/diagnostics", nil))
+	var diagnostics Diagnostics
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &diagnostics))
+	require.False(t, diagnostics.Ready, "not ready before the first probe")
+
+	probe.Probe(context.Background())
+	rec = httptest.NewRecorder()
+	probe.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/diagnostics", nil))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &diagnostics))
+
+	require.Len(t, diagnostics.Controllers, 2)
+	require.True(t, diagnostics.Controllers[0].Reachable)
+	require.NotNil(t, diagnostics.Controllers[0].LastSuccess)
+	require.False(t, diagnostics.Controllers[1].Reachable)
+	require.Contains(t, diagnostics.Controllers[1].LastError, "401")
+	require.NotNil(t, diagnostics.Controllers[1].LastErrorTime)
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/steadybit/extension-appdynamics/config"
+)
+
+// AppDynamicsClient is the part of the controller API used by the discoveries and actions, implemented by
+// appdclient.Client.
+type AppDynamicsClient interface {
+	BaseUrl() string
+	ListApplications(ctx context.Context) ([]appdclient.Application, error)
+	ListHealthRules(ctx context.Context, applicationId string) ([]appdclient.HealthRule, error)
+	GetViolations(ctx context.Context, applicationId string, start time.Time, end time.Time) ([]appdclient.Violation, error)
+	ListActionSuppressions(ctx context.Context, applicationId string) ([]appdclient.ActionSuppressionResponse, error)
+	CreateActionSuppression(ctx context.Context, applicationId string, request appdclient.ActionSuppressionRequest) (*appdclient.ActionSuppressionResponse, error)
+	DeleteActionSuppression(ctx context.Context, applicationId string, actionSuppressionId string) error
+	PostEvent(ctx context.Context, applicationId string, query string) (string, error)
+	ListMachines(ctx context.Context) ([]appdclient.Machine, error)
+	ListDatabaseCollectors(ctx context.Context) ([]appdclient.DatabaseCollector, error)
+	ListBackends(ctx context.Context, applicationId string) ([]appdclient.Backend, error)
+	GetMetricData(ctx context.Context, applicationId string, metricPath string, start time.Time, end time.Time) ([]appdclient.MetricData, error)
+}
+
+var _ AppDynamicsClient = (*appdclient.Client)(nil)
+
+// Controller is an AppDynamics controller targets are discovered from and actions are executed against.
+type Controller struct {
+	Name              string
+	ApplicationFilter []string
+	// ApplicationNameIncludes, ApplicationNameExcludes and ApplicationAccountGuids further limit the discovered
+	// applications, see listApplications. Like ApplicationFilter, they are overridden by the config file.
+	ApplicationNameIncludes config.NamePatterns
+	ApplicationNameExcludes config.NamePatterns
+	ApplicationAccountGuids []string
+	Client                  AppDynamicsClient
+	// Credentials are used to report the expiry of the access token, nil for clients without OAuth.
+	Credentials *appdclient.Credentials
+
+	applications applicationCache
+}
+
+// Controllers are all configured controllers. The first one is the default controller, which is used for targets that
+// do not carry a controller attribute.
+type Controllers []*Controller
+
+func (c Controllers) get(name string) (*Controller, error) {
+	if len(c) == 0 {
+		return nil, fmt.Errorf("no AppDynamics controller is configured")
+	}
+	if name == "" {
+		return c[0], nil
+	}
+	for _, controller := range c {
+		if controller.Name == name {
+			return controller, nil
+		}
+	}
+	return nil, fmt.Errorf("AppDynamics controller '%s' is not configured", name)
+}
+
+// getTargetId keeps the ids of the default controller's targets stable and prefixes the ids of all others, as ids are
+// only unique within a single controller.
+func (c *Controller) getTargetId(id string) string {
+	if c.Name == config.DefaultControllerName {
+		return id
+	}
+	return c.Name + "/" + id
+}
+
+func getControllerName(attributes map[string][]string, attribute string) string {
+	if values := attributes[attribute]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
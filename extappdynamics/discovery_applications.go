@@ -9,19 +9,20 @@ package extappdynamics
 
 import (
 	"context"
-	"github.com/go-resty/resty/v2"
+	"fmt"
 	"github.com/rs/zerolog/log"
 	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
 	"github.com/steadybit/discovery-kit/go/discovery_kit_commons"
 	"github.com/steadybit/discovery-kit/go/discovery_kit_sdk"
 	"github.com/steadybit/extension-appdynamics/config"
 	"github.com/steadybit/extension-kit/extbuild"
-	"k8s.io/utils/strings/slices"
 	"strconv"
 	"time"
 )
 
 type applicationDiscovery struct {
+	controllers Controllers
+	last        lastTargets
 }
 
 const (
@@ -29,6 +30,7 @@ const (
 	AppAccountGUID = ".account-guid"
 	AppDescription = ".description"
 	AppOrigin      = ".origin"
+	AppController  = ".controller"
 )
 
 var (
@@ -36,11 +38,11 @@ var (
 	_ discovery_kit_sdk.AttributeDescriber = (*applicationDiscovery)(nil)
 )
 
-func NewApplicationDiscovery() discovery_kit_sdk.TargetDiscovery {
-	discovery := &applicationDiscovery{}
+func NewApplicationDiscovery(controllers Controllers) discovery_kit_sdk.TargetDiscovery {
+	discovery := &applicationDiscovery{controllers: controllers}
 	return discovery_kit_sdk.NewCachedTargetDiscovery(discovery,
 		discovery_kit_sdk.WithRefreshTargetsNow(),
-		discovery_kit_sdk.WithRefreshTargetsInterval(context.Background(), 1*time.Minute),
+		discovery_kit_sdk.WithRefreshTargetsInterval(context.Background(), getDiscoveryInterval(config.Config.DiscoveryIntervalApplications)),
 	)
 }
 
@@ -48,7 +50,7 @@ func (d *applicationDiscovery) Describe() discovery_kit_api.DiscoveryDescription
 	return discovery_kit_api.DiscoveryDescription{
 		Id: applicationTargetType,
 		Discover: discovery_kit_api.DescribingEndpointReferenceWithCallInterval{
-			CallInterval: new("1m"),
+			CallInterval: new(getCallInterval(1*time.Minute, getDiscoveryInterval(config.Config.DiscoveryIntervalApplications))),
 		},
 	}
 }
@@ -67,6 +69,7 @@ func (d *applicationDiscovery) DescribeTarget() discovery_kit_api.TargetDescript
 				{Attribute: AppAttribute + AppDescription},
 				{Attribute: AppAttribute + AppAccountGUID},
 				{Attribute: AppAttribute + AppOrigin},
+				{Attribute: AppAttribute + AppController},
 			},
 			OrderBy: []discovery_kit_api.OrderBy{
 				{
@@ -110,45 +113,42 @@ func (d *applicationDiscovery) DescribeAttributes() []discovery_kit_api.Attribut
 				One:   "Controller Url",
 				Other: "Controller Urls",
 			},
+		}, {
+			Attribute: AppAttribute + AppController,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Controller",
+				Other: "Controllers",
+			},
+		}, {
+			Attribute: AppAttribute + AttributeStale,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Stale",
+				Other: "Stale",
+			},
 		},
 	}
 }
 
 func (d *applicationDiscovery) DiscoverTargets(ctx context.Context) ([]discovery_kit_api.Target, error) {
-	return discovery_kit_commons.ApplyAttributeExcludes(getAllApplications(ctx, RestyClient), config.Config.DiscoveryAttributesExcludesApplications), nil
-}
-
-func getAllApplications(ctx context.Context, client *resty.Client) []discovery_kit_api.Target {
-	if client == nil {
-		log.Error().Msg("Client is nil.")
-	}
-	applications := []Application{}
-	result := make([]discovery_kit_api.Target, 0, 1000)
-	res, err := client.R().
-		SetContext(ctx).
-		SetResult(&applications).
-		Get("/controller/rest/applications?output=JSON")
-
+	result, err := discoverTargets(ctx, d.controllers, &d.last, applicationTargetType, AppAttribute, getAllApplications)
 	if err != nil {
-		log.Err(err).Msg("Failed to retrieve applications from AppDynamics.")
-		return result
+		return nil, fmt.Errorf("failed to discover applications: %w", err)
 	}
+	return discovery_kit_commons.ApplyAttributeExcludes(result, config.GetDiscoveryAttributesExcludesApplications()), nil
+}
 
-	if res.StatusCode() != 200 {
-		log.Warn().Msgf("AppDynamics API responded with unexpected status code %d while retrieving applications. Full response: %v",
-			res.StatusCode(),
-			res.String())
-	} else {
-		log.Trace().Msgf("AppDynamics response: %v", applications)
+func getAllApplications(ctx context.Context, controller *Controller) ([]discovery_kit_api.Target, error) {
+	applications, err := controller.listApplications(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve applications: %w", err)
 	}
+	result := make([]discovery_kit_api.Target, 0, len(applications))
+	log.Trace().Msgf("AppDynamics response: %v", applications)
 
 	for _, app := range applications {
 		appId := strconv.Itoa(app.ID)
-		if len(config.Config.ApplicationFilter) > 0 && !slices.Contains(config.Config.ApplicationFilter, appId) {
-			continue
-		}
 		result = append(result, discovery_kit_api.Target{
-			Id:         appId,
+			Id:         controller.getTargetId(appId),
 			TargetType: applicationTargetType,
 			Label:      app.Name,
 			Attributes: map[string][]string{
@@ -156,9 +156,10 @@ func getAllApplications(ctx context.Context, client *resty.Client) []discovery_k
 				AppAttribute + ".name":        {app.Name},
 				AppAttribute + ".id":          {appId},
 				AppAttribute + AppAccountGUID: {app.AccountGUID},
-				AppAttribute + AppOrigin:      {config.Config.ApiBaseUrl},
+				AppAttribute + AppOrigin:      {controller.Client.BaseUrl()},
+				AppAttribute + AppController:  {controller.Name},
 			}})
 	}
 
-	return result
+	return result, nil
 }
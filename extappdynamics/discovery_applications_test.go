@@ -9,16 +9,17 @@ import (
 	"testing"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/steadybit/extension-appdynamics/appdclient"
 )
 
 // helper to create a Resty client pointing at our test server
-func newTestClient(ts *httptest.Server) *resty.Client {
-	return resty.New().SetBaseURL(ts.URL)
+func newTestClient(ts *httptest.Server) *appdclient.Client {
+	return appdclient.New(resty.New().SetBaseURL(ts.URL))
 }
 
 func TestGetAllApplications_Success(t *testing.T) {
 	// prepare a fake AppDynamics JSON payload
-	apps := []Application{{ID: 123, Name: "TestApp", Description: "Test Desc", AccountGUID: "GUID-123"}}
+	apps := []appdclient.Application{{ID: 123, Name: "TestApp", Description: "Test Desc", AccountGUID: "GUID-123"}}
 	appsbytes, err := json.Marshal(apps)
 	if err != nil {
 		t.Fatalf("unexpected err: %s", err)
@@ -35,10 +36,8 @@ func TestGetAllApplications_Success(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	client := resty.New().SetBaseURL(ts.URL).SetHeader("Accept", "application/json")
-	RestyClient = client
-
-	appDiscovery := &applicationDiscovery{}
+	client := appdclient.New(resty.New().SetBaseURL(ts.URL).SetHeader("Accept", "application/json"))
+	appDiscovery := &applicationDiscovery{controllers: Controllers{{Name: "default", Client: client}}}
 	targets, err := appDiscovery.DiscoverTargets(context.Background())
 	if len(targets) != 1 {
 		t.Fatalf("expected 1 target, got %d", len(targets))
@@ -63,6 +62,39 @@ func TestGetAllApplications_Success(t *testing.T) {
 	}
 }
 
+func TestApplicationDiscovery_MultipleControllers(t *testing.T) {
+	newController := func(name string, apps []appdclient.Application) *Controller {
+		appsbytes, err := json.Marshal(apps)
+		if err != nil {
+			t.Fatalf("unexpected err: %s", err)
+		}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(appsbytes)
+		}))
+		t.Cleanup(ts.Close)
+		return &Controller{Name: name, Client: newTestClient(ts)}
+	}
+	saas := newController("default", []appdclient.Application{{ID: 1, Name: "checkout"}})
+	onprem := newController("onprem", []appdclient.Application{{ID: 1, Name: "ledger"}})
+	targets, err := (&applicationDiscovery{controllers: Controllers{saas, onprem}}).DiscoverTargets(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 targets, got %d", len(targets))
+	}
+	if targets[0].Id != "1" || targets[1].Id != "onprem/1" {
+		t.Errorf("expected ids \"1\" and \"onprem/1\", got %q and %q", targets[0].Id, targets[1].Id)
+	}
+	if targets[1].Attributes[AppAttribute+AppController][0] != "onprem" {
+		t.Errorf("expected controller=\"onprem\", got %q", targets[1].Attributes[AppAttribute+AppController][0])
+	}
+	if targets[1].Attributes[AppAttribute+AppOrigin][0] != onprem.Client.BaseUrl() {
+		t.Errorf("expected origin=%q, got %q", onprem.Client.BaseUrl(), targets[1].Attributes[AppAttribute+AppOrigin][0])
+	}
+}
+
 func TestGetAllApplications_Non200(t *testing.T) {
 	// server returns 500 Internal Server Error
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -71,8 +103,11 @@ func TestGetAllApplications_Non200(t *testing.T) {
 	defer ts.Close()
 
 	client := newTestClient(ts)
-	targets := getAllApplications(context.Background(), client)
+	targets, err := getAllApplications(context.Background(), &Controller{Name: "default", Client: client})
 
+	if err == nil {
+		t.Errorf("expected an error on non-200")
+	}
 	if len(targets) != 0 {
 		t.Fatalf("expected 0 targets on non-200, got %d", len(targets))
 	}
@@ -101,8 +136,8 @@ func TestApplicationDiscovery_DescribeTarget(t *testing.T) {
 	if td.Category == nil || *td.Category != "monitoring" {
 		t.Errorf("expected Category=\"monitoring\", got %v", td.Category)
 	}
-	if len(td.Table.Columns) != 5 {
-		t.Errorf("expected 5 table columns, got %d", len(td.Table.Columns))
+	if len(td.Table.Columns) != 6 {
+		t.Errorf("expected 6 table columns, got %d", len(td.Table.Columns))
 	}
 }
 
@@ -115,6 +150,8 @@ func TestApplicationDiscovery_DescribeAttributes(t *testing.T) {
 		AppAttribute + ".description",
 		AppAttribute + AppAccountGUID,
 		AppAttribute + AppOrigin,
+		AppAttribute + AppController,
+		AppAttribute + AttributeStale,
 	}
 	if len(attrs) != len(want) {
 		t.Fatalf("DescribeAttributes() len = %d; want %d", len(attrs), len(want))
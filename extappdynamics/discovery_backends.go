@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_sdk"
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/steadybit/extension-appdynamics/config"
+	"github.com/steadybit/extension-kit/extbuild"
+)
+
+type backendDiscovery struct {
+	controllers Controllers
+	last        lastTargets
+}
+
+const (
+	BackendAttribute = "appdynamics.backend"
+	BackendType      = ".type"
+	// BackendProperty prefixes the properties of a backend, e.g. "appdynamics.backend.property.host".
+	BackendProperty = ".property."
+)
+
+var (
+	_ discovery_kit_sdk.TargetDescriber    = (*backendDiscovery)(nil)
+	_ discovery_kit_sdk.AttributeDescriber = (*backendDiscovery)(nil)
+)
+
+func NewBackendDiscovery(controllers Controllers) discovery_kit_sdk.TargetDiscovery {
+	discovery := &backendDiscovery{controllers: controllers}
+	return discovery_kit_sdk.NewCachedTargetDiscovery(discovery,
+		discovery_kit_sdk.WithRefreshTargetsNow(),
+		discovery_kit_sdk.WithRefreshTargetsInterval(context.Background(), getDiscoveryInterval(config.Config.DiscoveryIntervalBackends)),
+	)
+}
+
+func (d *backendDiscovery) Describe() discovery_kit_api.DiscoveryDescription {
+	return discovery_kit_api.DiscoveryDescription{
+		Id: backendTargetType,
+		Discover: discovery_kit_api.DescribingEndpointReferenceWithCallInterval{
+			CallInterval: new(getCallInterval(2*time.Minute, getDiscoveryInterval(config.Config.DiscoveryIntervalBackends))),
+		},
+	}
+}
+
+func (d *backendDiscovery) DescribeTarget() discovery_kit_api.TargetDescription {
+	return discovery_kit_api.TargetDescription{
+		Id:       backendTargetType,
+		Label:    discovery_kit_api.PluralLabel{One: "AppDynamics backend", Other: "AppDynamics backends"},
+		Category: new("monitoring"),
+		Version:  extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:     new(appDynamicsTargetIcon),
+		Table: discovery_kit_api.Table{
+			Columns: []discovery_kit_api.Column{
+				{Attribute: BackendAttribute + ".name"},
+				{Attribute: BackendAttribute + BackendType},
+				{Attribute: BackendAttribute + AttributeAppName},
+				{Attribute: BackendAttribute + AttributeController},
+			},
+			OrderBy: []discovery_kit_api.OrderBy{
+				{
+					Attribute: BackendAttribute + ".name",
+					Direction: "ASC",
+				},
+			},
+		},
+	}
+}
+
+func (d *backendDiscovery) DescribeAttributes() []discovery_kit_api.AttributeDescription {
+	return []discovery_kit_api.AttributeDescription{
+		{
+			Attribute: BackendAttribute + ".name",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Backend",
+				Other: "Backends",
+			},
+		}, {
+			Attribute: BackendAttribute + ".id",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "ID",
+				Other: "IDs",
+			},
+		}, {
+			Attribute: BackendAttribute + BackendType,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Backend type",
+				Other: "Backend types",
+			},
+		}, {
+			Attribute: BackendAttribute + AttributeAppID,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Backend application id",
+				Other: "Backend application ids",
+			},
+		}, {
+			Attribute: BackendAttribute + AttributeAppName,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Backend application name",
+				Other: "Backend application names",
+			},
+		}, {
+			Attribute: BackendAttribute + AttributeOrigin,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Backend controller url",
+				Other: "Backend controller urls",
+			},
+		}, {
+			Attribute: BackendAttribute + AttributeController,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Backend controller",
+				Other: "Backend controllers",
+			},
+		}, {
+			Attribute: BackendAttribute + AttributeStale,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Stale",
+				Other: "Stale",
+			},
+		},
+	}
+}
+
+func (d *backendDiscovery) DiscoverTargets(ctx context.Context) ([]discovery_kit_api.Target, error) {
+	result, err := discoverTargets(ctx, d.controllers, &d.last, backendTargetType, BackendAttribute, getAllBackends)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover backends: %w", err)
+	}
+	return result, nil
+}
+
+// getAllBackends lists the backends of all applications of the controller, see discoverApplicationTargets.
+func getAllBackends(ctx context.Context, controller *Controller) ([]discovery_kit_api.Target, error) {
+	return discoverApplicationTargets(ctx, controller, "backends", config.Config.BackendDiscoveryConcurrency, getBackends)
+}
+
+func getBackends(ctx context.Context, controller *Controller, app appdclient.Application) ([]discovery_kit_api.Target, error) {
+	appId := strconv.Itoa(app.ID)
+	backends, err := controller.Client.ListBackends(ctx, appId)
+	if err != nil {
+		return nil, err
+	}
+	log.Trace().Msgf("AppDynamics response: %v", backends)
+
+	result := make([]discovery_kit_api.Target, 0, len(backends))
+	for _, backend := range backends {
+		backendId := strconv.FormatInt(backend.ID, 10)
+		attributes := map[string][]string{
+			BackendAttribute + ".name":             {backend.Name},
+			BackendAttribute + ".id":               {backendId},
+			BackendAttribute + BackendType:         {backend.ExitPointType},
+			BackendAttribute + AttributeAppID:      {appId},
+			BackendAttribute + AttributeAppName:    {app.Name},
+			BackendAttribute + AttributeOrigin:     {controller.Client.BaseUrl()},
+			BackendAttribute + AttributeController: {controller.Name},
+		}
+		for _, property := range backend.Properties {
+			if property.Value != "" {
+				key := BackendAttribute + BackendProperty + strings.ToLower(strings.ReplaceAll(property.Name, " ", "-"))
+				attributes[key] = append(attributes[key], property.Value)
+			}
+		}
+		result = append(result, discovery_kit_api.Target{
+			Id:         controller.getTargetId(appId + "-backend-" + backendId),
+			TargetType: backendTargetType,
+			Label:      backend.Name,
+			Attributes: attributes,
+		})
+	}
+	return result, nil
+}
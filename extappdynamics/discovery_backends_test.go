@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackendDiscovery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/controller/rest/applications":
+			_, _ = w.Write([]byte(`[{"id":42,"name":"checkout"},{"id":43,"name":"ledger"}]`))
+		case "/controller/rest/applications/42/backends":
+			_, _ = w.Write([]byte(`[{"id":7,"name":"payments.example.com:443","exitPointType":"HTTP","tierId":0,
+				"properties":[{"id":1,"name":"HOST","value":"payments.example.com"},{"id":2,"name":"PORT","value":"443"}]}]`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+
+	discovery := &backendDiscovery{controllers: Controllers{{Name: "default", Client: newTestClient(ts)}}}
+	targets, err := discovery.DiscoverTargets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+
+	payments := targets[0]
+	assert.Equal(t, backendTargetType, payments.TargetType)
+	assert.Equal(t, "payments.example.com:443", payments.Label)
+	assert.Equal(t, []string{"HTTP"}, payments.Attributes[BackendAttribute+BackendType])
+	assert.Equal(t, []string{"42"}, payments.Attributes[BackendAttribute+AttributeAppID])
+	assert.Equal(t, []string{"checkout"}, payments.Attributes[BackendAttribute+AttributeAppName])
+	assert.Equal(t, []string{"payments.example.com"}, payments.Attributes[BackendAttribute+BackendProperty+"host"])
+	assert.Equal(t, []string{"443"}, payments.Attributes[BackendAttribute+BackendProperty+"port"])
+}
+
+func TestBackendCheckPrepare(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	action := NewBackendCheckAction(Controllers{{Name: "default", Client: newTestClient(ts)}})
+	state := action.NewEmptyState()
+	_, err := action.Prepare(context.Background(), &state, action_kit_api.PrepareActionRequestBody{
+		Config: map[string]any{
+			"duration":       float64(60000),
+			"metric":         "Average Response Time (ms)",
+			"expectation":    MetricExpectationAbove,
+			"threshold":      float64(500),
+			"stateCheckMode": StateCheckModeAtLeastOnce,
+		},
+		Target: &action_kit_api.Target{Attributes: map[string][]string{
+			BackendAttribute + ".id":               {"7"},
+			BackendAttribute + ".name":             {"payments.example.com:443"},
+			BackendAttribute + AttributeAppID:      {"42"},
+			BackendAttribute + AttributeController: {"default"},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "42", state.ApplicationId)
+	assert.Equal(t, "Backends|payments.example.com:443|Average Response Time (ms)", state.MetricPath)
+	assert.Equal(t, "Average response time (ms)", state.MetricLabel)
+	assert.Equal(t, int64(500), state.Threshold)
+	assert.Equal(t, StateCheckModeAtLeastOnce, state.StateCheckMode)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), state.End, 5*time.Second)
+}
+
+func TestBackendCheckStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("metric-path") {
+		case "Backends|payments.example.com:443|Errors per Minute":
+			_, _ = w.Write([]byte(`[{"metricPath":"Errors per Minute","metricValues":[{"startTimeInMillis":1000,"value":12}]}]`))
+		case "Backends|payments.example.com:443|Average Response Time (ms)":
+			_, _ = w.Write([]byte(`[{"metricPath":"Average Response Time (ms)","metricValues":[{"startTimeInMillis":1000,"value":650}]}]`))
+		default:
+			t.Errorf("unexpected metric path %s", r.URL.Query().Get("metric-path"))
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name        string
+		metric      string
+		expectation string
+		threshold   float64
+		error       string
+	}{
+		{"errors below threshold", "Errors per Minute", MetricExpectationBelow, 5, "Errors per minute of 'payments.example.com:443' is 12 whereas it is expected to be below 5"},
+		{"errors above threshold", "Errors per Minute", MetricExpectationAbove, 5, ""},
+		{"response time above threshold", "Average Response Time (ms)", MetricExpectationAbove, 500, ""},
+		{"response time below threshold", "Average Response Time (ms)", MetricExpectationBelow, 500, "Average response time (ms) of 'payments.example.com:443' is 650 whereas it is expected to be below 500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controllers := Controllers{{Name: "default", Client: newTestClient(ts)}}
+			action := NewBackendCheckAction(controllers)
+			state := action.NewEmptyState()
+			_, err := action.Prepare(context.Background(), &state, action_kit_api.PrepareActionRequestBody{
+				Config: map[string]any{
+					"duration":       float64(60000),
+					"metric":         tt.metric,
+					"expectation":    tt.expectation,
+					"threshold":      tt.threshold,
+					"stateCheckMode": StateCheckModeAllTheTime,
+				},
+				Target: &action_kit_api.Target{Attributes: map[string][]string{
+					BackendAttribute + ".id":               {"7"},
+					BackendAttribute + ".name":             {"payments.example.com:443"},
+					BackendAttribute + AttributeAppID:      {"42"},
+					BackendAttribute + AttributeController: {"default"},
+				}},
+			})
+			require.NoError(t, err)
+
+			state.End = time.Now().Add(-time.Second)
+			result, err := MetricCheckStatus(context.Background(), &state, controllers[0].Client)
+			require.NoError(t, err)
+			assert.True(t, result.Completed)
+			if tt.error == "" {
+				assert.Nil(t, result.Error)
+			} else {
+				require.NotNil(t, result.Error)
+				assert.Contains(t, result.Error.Title, tt.error)
+			}
+		})
+	}
+}
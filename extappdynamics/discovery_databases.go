@@ -0,0 +1,182 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_sdk"
+	"github.com/steadybit/extension-appdynamics/config"
+	"github.com/steadybit/extension-kit/extbuild"
+)
+
+type databaseDiscovery struct {
+	controllers Controllers
+	last        lastTargets
+}
+
+const (
+	DatabaseAttribute = "appdynamics.database"
+	DatabaseType      = ".type"
+	DatabaseHostname  = ".hostname"
+	DatabasePort      = ".port"
+	DatabaseAgent     = ".agent"
+)
+
+var (
+	_ discovery_kit_sdk.TargetDescriber    = (*databaseDiscovery)(nil)
+	_ discovery_kit_sdk.AttributeDescriber = (*databaseDiscovery)(nil)
+)
+
+func NewDatabaseDiscovery(controllers Controllers) discovery_kit_sdk.TargetDiscovery {
+	discovery := &databaseDiscovery{controllers: controllers}
+	return discovery_kit_sdk.NewCachedTargetDiscovery(discovery,
+		discovery_kit_sdk.WithRefreshTargetsNow(),
+		discovery_kit_sdk.WithRefreshTargetsInterval(context.Background(), getDiscoveryInterval(config.Config.DiscoveryIntervalDatabases)),
+	)
+}
+
+func (d *databaseDiscovery) Describe() discovery_kit_api.DiscoveryDescription {
+	return discovery_kit_api.DiscoveryDescription{
+		Id: databaseTargetType,
+		Discover: discovery_kit_api.DescribingEndpointReferenceWithCallInterval{
+			CallInterval: new(getCallInterval(1*time.Minute, getDiscoveryInterval(config.Config.DiscoveryIntervalDatabases))),
+		},
+	}
+}
+
+func (d *databaseDiscovery) DescribeTarget() discovery_kit_api.TargetDescription {
+	return discovery_kit_api.TargetDescription{
+		Id:       databaseTargetType,
+		Label:    discovery_kit_api.PluralLabel{One: "AppDynamics database", Other: "AppDynamics databases"},
+		Category: new("monitoring"),
+		Version:  extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:     new(appDynamicsTargetIcon),
+		Table: discovery_kit_api.Table{
+			Columns: []discovery_kit_api.Column{
+				{Attribute: DatabaseAttribute + ".name"},
+				{Attribute: DatabaseAttribute + DatabaseType},
+				{Attribute: DatabaseAttribute + DatabaseHostname},
+				{Attribute: DatabaseAttribute + AttributeController},
+			},
+			OrderBy: []discovery_kit_api.OrderBy{
+				{
+					Attribute: DatabaseAttribute + ".name",
+					Direction: "ASC",
+				},
+			},
+		},
+	}
+}
+
+func (d *databaseDiscovery) DescribeAttributes() []discovery_kit_api.AttributeDescription {
+	return []discovery_kit_api.AttributeDescription{
+		{
+			Attribute: DatabaseAttribute + ".name",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Database collector",
+				Other: "Database collectors",
+			},
+		}, {
+			Attribute: DatabaseAttribute + ".id",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "ID",
+				Other: "IDs",
+			},
+		}, {
+			Attribute: DatabaseAttribute + DatabaseType,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Database type",
+				Other: "Database types",
+			},
+		}, {
+			Attribute: DatabaseAttribute + DatabaseHostname,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Database host",
+				Other: "Database hosts",
+			},
+		}, {
+			Attribute: DatabaseAttribute + DatabasePort,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Database port",
+				Other: "Database ports",
+			},
+		}, {
+			Attribute: DatabaseAttribute + DatabaseAgent,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Database agent",
+				Other: "Database agents",
+			},
+		}, {
+			Attribute: DatabaseAttribute + AttributeOrigin,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Database controller url",
+				Other: "Database controller urls",
+			},
+		}, {
+			Attribute: DatabaseAttribute + AttributeController,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Database controller",
+				Other: "Database controllers",
+			},
+		}, {
+			Attribute: DatabaseAttribute + AttributeStale,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Stale",
+				Other: "Stale",
+			},
+		},
+	}
+}
+
+func (d *databaseDiscovery) DiscoverTargets(ctx context.Context) ([]discovery_kit_api.Target, error) {
+	result, err := discoverTargets(ctx, d.controllers, &d.last, databaseTargetType, DatabaseAttribute, getAllDatabases)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover databases: %w", err)
+	}
+	return result, nil
+}
+
+func getAllDatabases(ctx context.Context, controller *Controller) ([]discovery_kit_api.Target, error) {
+	collectors, err := controller.Client.ListDatabaseCollectors(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve database collectors: %w", err)
+	}
+	log.Trace().Msgf("AppDynamics response: %v", collectors)
+
+	result := make([]discovery_kit_api.Target, 0, len(collectors))
+	for _, collector := range collectors {
+		if !collector.Enabled {
+			continue
+		}
+		collectorId := strconv.FormatInt(collector.ID, 10)
+		attributes := map[string][]string{
+			DatabaseAttribute + ".name":             {collector.Name},
+			DatabaseAttribute + ".id":               {collectorId},
+			DatabaseAttribute + DatabaseType:        {strings.ToLower(collector.Type)},
+			DatabaseAttribute + DatabaseHostname:    {collector.Hostname},
+			DatabaseAttribute + AttributeOrigin:     {controller.Client.BaseUrl()},
+			DatabaseAttribute + AttributeController: {controller.Name},
+		}
+		if collector.Port > 0 {
+			attributes[DatabaseAttribute+DatabasePort] = []string{strconv.Itoa(collector.Port)}
+		}
+		if collector.AgentName != "" {
+			attributes[DatabaseAttribute+DatabaseAgent] = []string{collector.AgentName}
+		}
+		result = append(result, discovery_kit_api.Target{
+			Id:         controller.getTargetId("database-" + collectorId),
+			TargetType: databaseTargetType,
+			Label:      collector.Name,
+			Attributes: attributes,
+		})
+	}
+	return result, nil
+}
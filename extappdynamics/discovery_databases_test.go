@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseDiscovery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/controller/rest/databases/collectors" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"id":3,"name":"orders-db","type":"MYSQL","hostname":"orders-db.example.com","port":3306,"agentName":"Default Database Agent","enabled":true},
+			{"id":4,"name":"legacy-db","type":"ORACLE","hostname":"legacy-db.example.com","enabled":false}
+		]`))
+	}))
+	defer ts.Close()
+
+	discovery := &databaseDiscovery{controllers: Controllers{{Name: "default", Client: newTestClient(ts)}}}
+	targets, err := discovery.DiscoverTargets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+
+	orders := targets[0]
+	assert.Equal(t, databaseTargetType, orders.TargetType)
+	assert.Equal(t, "orders-db", orders.Label)
+	assert.Equal(t, []string{"mysql"}, orders.Attributes[DatabaseAttribute+DatabaseType])
+	assert.Equal(t, []string{"orders-db.example.com"}, orders.Attributes[DatabaseAttribute+DatabaseHostname])
+	assert.Equal(t, []string{"3306"}, orders.Attributes[DatabaseAttribute+DatabasePort])
+	assert.Equal(t, []string{"Default Database Agent"}, orders.Attributes[DatabaseAttribute+DatabaseAgent])
+	assert.Equal(t, []string{"default"}, orders.Attributes[DatabaseAttribute+AttributeController])
+}
@@ -9,19 +9,21 @@ package extappdynamics
 
 import (
 	"context"
-	"github.com/go-resty/resty/v2"
+	"fmt"
 	"github.com/rs/zerolog/log"
 	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
 	"github.com/steadybit/discovery-kit/go/discovery_kit_commons"
 	"github.com/steadybit/discovery-kit/go/discovery_kit_sdk"
+	"github.com/steadybit/extension-appdynamics/appdclient"
 	"github.com/steadybit/extension-appdynamics/config"
 	"github.com/steadybit/extension-kit/extbuild"
-	"k8s.io/utils/strings/slices"
 	"strconv"
 	"time"
 )
 
 type healthRuleDiscovery struct {
+	controllers Controllers
+	last        lastTargets
 }
 
 const (
@@ -31,6 +33,7 @@ const (
 	AttributeAppID              = ".application.id"
 	AttributeAppName            = ".application.name"
 	AttributeOrigin             = ".origin"
+	AttributeController         = ".controller"
 )
 
 var (
@@ -38,11 +41,11 @@ var (
 	_ discovery_kit_sdk.AttributeDescriber = (*healthRuleDiscovery)(nil)
 )
 
-func NewHealthRuleDiscovery() discovery_kit_sdk.TargetDiscovery {
-	discovery := &healthRuleDiscovery{}
+func NewHealthRuleDiscovery(controllers Controllers) discovery_kit_sdk.TargetDiscovery {
+	discovery := &healthRuleDiscovery{controllers: controllers}
 	return discovery_kit_sdk.NewCachedTargetDiscovery(discovery,
 		discovery_kit_sdk.WithRefreshTargetsNow(),
-		discovery_kit_sdk.WithRefreshTargetsInterval(context.Background(), 1*time.Minute),
+		discovery_kit_sdk.WithRefreshTargetsInterval(context.Background(), getDiscoveryInterval(config.Config.DiscoveryIntervalHealthRules)),
 	)
 }
 
@@ -50,7 +53,7 @@ func (d *healthRuleDiscovery) Describe() discovery_kit_api.DiscoveryDescription
 	return discovery_kit_api.DiscoveryDescription{
 		Id: applicationHealthRuleTargetType,
 		Discover: discovery_kit_api.DescribingEndpointReferenceWithCallInterval{
-			CallInterval: new("2m"),
+			CallInterval: new(getCallInterval(2*time.Minute, getDiscoveryInterval(config.Config.DiscoveryIntervalHealthRules))),
 		},
 	}
 }
@@ -71,6 +74,7 @@ func (d *healthRuleDiscovery) DescribeTarget() discovery_kit_api.TargetDescripti
 				{Attribute: HealthRuleAttribute + AttributeAppID},
 				{Attribute: HealthRuleAttribute + AttributeAppName},
 				{Attribute: HealthRuleAttribute + AttributeOrigin},
+				{Attribute: HealthRuleAttribute + AttributeController},
 			},
 			OrderBy: []discovery_kit_api.OrderBy{
 				{
@@ -126,76 +130,60 @@ func (d *healthRuleDiscovery) DescribeAttributes() []discovery_kit_api.Attribute
 				One:   "Health rule controller url",
 				Other: "Health rule controller urls",
 			},
+		}, {
+			Attribute: HealthRuleAttribute + AttributeController,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Health rule controller",
+				Other: "Health rule controllers",
+			},
+		}, {
+			Attribute: HealthRuleAttribute + AttributeStale,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Stale",
+				Other: "Stale",
+			},
 		},
 	}
 }
 
 func (d *healthRuleDiscovery) DiscoverTargets(ctx context.Context) ([]discovery_kit_api.Target, error) {
-	return discovery_kit_commons.ApplyAttributeExcludes(getAllHealthRules(ctx, RestyClient), config.Config.DiscoveryAttributesExcludesHealthRules), nil
+	result, err := discoverTargets(ctx, d.controllers, &d.last, applicationHealthRuleTargetType, HealthRuleAttribute, getAllHealthRules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover health rules: %w", err)
+	}
+	return discovery_kit_commons.ApplyAttributeExcludes(result, config.GetDiscoveryAttributesExcludesHealthRules()), nil
 }
 
-func getAllHealthRules(ctx context.Context, client *resty.Client) []discovery_kit_api.Target {
-	var applications []Application
-	var healthRules []HealthRule
-
-	result := make([]discovery_kit_api.Target, 0, 1000)
-	res, err := client.R().
-		SetContext(ctx).
-		SetResult(&applications).
-		Get("/controller/rest/applications?output=JSON")
+// getAllHealthRules lists the health rules of all applications of the controller. Applications whose health rules can't
+// be listed, e.g. due to missing permissions, are skipped, see discoverApplicationTargets.
+func getAllHealthRules(ctx context.Context, controller *Controller) ([]discovery_kit_api.Target, error) {
+	return discoverApplicationTargets(ctx, controller, "health rules", config.Config.HealthRuleDiscoveryConcurrency, getHealthRules)
+}
 
+func getHealthRules(ctx context.Context, controller *Controller, app appdclient.Application) ([]discovery_kit_api.Target, error) {
+	appId := strconv.Itoa(app.ID)
+	healthRules, err := controller.Client.ListHealthRules(ctx, appId)
 	if err != nil {
-		log.Err(err).Msg("Failed to retrieve applications from AppDynamics.")
-		return result
-	}
-
-	if res.StatusCode() != 200 {
-		log.Warn().Msgf("AppDynamics API responded with unexpected status code %d while retrieving applications. Full response: %v",
-			res.StatusCode(),
-			res.String())
-	} else {
-		log.Trace().Msgf("AppDynamics response: %v", applications)
+		return nil, err
 	}
-
-	for _, app := range applications {
-		appId := strconv.Itoa(app.ID)
-		if len(config.Config.ApplicationFilter) > 0 && !slices.Contains(config.Config.ApplicationFilter, appId) {
-			continue
-		}
-
-		res, err := client.R().
-			SetContext(ctx).
-			SetResult(&healthRules).
-			Get("/controller/alerting/rest/v1/applications/" + strconv.Itoa(app.ID) + "/health-rules?output=JSON")
-
-		if err != nil {
-			log.Err(err).Msgf("Failed to retrieve health rules from AppDynamics with application %d.", app.ID)
-			return result
-		}
-
-		if res.StatusCode() != 200 {
-			log.Warn().Msgf("AppDynamics API responded with unexpected status code %d while retrieving health rules. Full response: %v",
-				res.StatusCode(),
-				res.String())
-		} else {
-			log.Trace().Msgf("AppDynamics response: %v", applications)
-		}
-		for _, healthRule := range healthRules {
-			result = append(result, discovery_kit_api.Target{
-				Id:         strconv.Itoa(app.ID) + "-" + strconv.Itoa(healthRule.ID),
-				TargetType: applicationHealthRuleTargetType,
-				Label:      healthRule.Name,
-				Attributes: map[string][]string{
-					HealthRuleAttribute + ".name":                     {healthRule.Name},
-					HealthRuleAttribute + ".id":                       {strconv.Itoa(healthRule.ID)},
-					HealthRuleAttribute + AttributeEnabled:            {strconv.FormatBool(healthRule.Enabled)},
-					HealthRuleAttribute + AttributeAffectedEntityType: {healthRule.AffectedEntityType},
-					HealthRuleAttribute + AttributeAppID:              {strconv.Itoa(app.ID)},
-					HealthRuleAttribute + AttributeAppName:            {app.Name},
-					HealthRuleAttribute + AttributeOrigin:             {config.Config.ApiBaseUrl},
-				}})
-		}
+	log.Trace().Msgf("AppDynamics response: %v", healthRules)
+
+	result := make([]discovery_kit_api.Target, 0, len(healthRules))
+	for _, healthRule := range healthRules {
+		result = append(result, discovery_kit_api.Target{
+			Id:         controller.getTargetId(appId + "-" + strconv.Itoa(healthRule.ID)),
+			TargetType: applicationHealthRuleTargetType,
+			Label:      healthRule.Name,
+			Attributes: map[string][]string{
+				HealthRuleAttribute + ".name":                     {healthRule.Name},
+				HealthRuleAttribute + ".id":                       {strconv.Itoa(healthRule.ID)},
+				HealthRuleAttribute + AttributeEnabled:            {strconv.FormatBool(healthRule.Enabled)},
+				HealthRuleAttribute + AttributeAffectedEntityType: {healthRule.AffectedEntityType},
+				HealthRuleAttribute + AttributeAppID:              {appId},
+				HealthRuleAttribute + AttributeAppName:            {app.Name},
+				HealthRuleAttribute + AttributeOrigin:             {controller.Client.BaseUrl()},
+				HealthRuleAttribute + AttributeController:         {controller.Name},
+			}})
 	}
-
-	return result
+	return result, nil
 }
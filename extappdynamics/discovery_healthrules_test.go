@@ -6,10 +6,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/steadybit/extension-appdynamics/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -40,8 +43,9 @@ func TestGetAllHealthRules_Success(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	client := resty.New().SetHostURL(ts.URL)
-	targets := getAllHealthRules(context.Background(), client)
+	client := appdclient.New(resty.New().SetHostURL(ts.URL))
+	targets, err := getAllHealthRules(context.Background(), &Controller{Name: "default", Client: client})
+	assert.NoError(t, err)
 
 	assert.Len(t, targets, 1)
 	hr := targets[0]
@@ -54,9 +58,11 @@ func TestGetAllHealthRules_Success(t *testing.T) {
 	assert.Equal(t, "true", attrs[HealthRuleAttribute+AttributeEnabled][0])
 	assert.Equal(t, "APPLICATION", attrs[HealthRuleAttribute+AttributeAffectedEntityType][0])
 	assert.Equal(t, "42", attrs[HealthRuleAttribute+AttributeAppID][0])
+	assert.Equal(t, ts.URL, attrs[HealthRuleAttribute+AttributeOrigin][0])
+	assert.Equal(t, "default", attrs[HealthRuleAttribute+AttributeController][0])
 }
 
-// If the applications endpoint fails, we should get zero targets
+// If the applications endpoint fails, we should get an error and zero targets
 func TestGetAllHealthRules_ApplicationsNon200(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -65,11 +71,45 @@ func TestGetAllHealthRules_ApplicationsNon200(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	client := resty.New().SetHostURL(ts.URL)
-	targets := getAllHealthRules(context.Background(), client)
+	client := appdclient.New(resty.New().SetHostURL(ts.URL))
+	targets, err := getAllHealthRules(context.Background(), &Controller{Name: "default", Client: client})
+	assert.Error(t, err)
 	assert.Empty(t, targets)
 }
 
+// An application whose health rules can't be listed must not hide the health rules of the other applications
+func TestGetAllHealthRules_SkipsFailingApplications(t *testing.T) {
+	config.Config.HealthRuleDiscoveryConcurrency = 2
+	t.Cleanup(func() { config.Config.HealthRuleDiscoveryConcurrency = 0 })
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.RequestURI() {
+		case "/controller/rest/applications?output=JSON":
+			_, _ = w.Write([]byte(`[{"id": 1, "name": "App1"}, {"id": 2, "name": "App2"}, {"id": 3, "name": "App3"}]`))
+		case "/controller/alerting/rest/v1/applications/1/health-rules?output=JSON":
+			_, _ = w.Write([]byte(`[{"id": 10, "name": "Rule10"}]`))
+		case "/controller/alerting/rest/v1/applications/2/health-rules?output=JSON":
+			w.WriteHeader(http.StatusForbidden)
+		case "/controller/alerting/rest/v1/applications/3/health-rules?output=JSON":
+			_, _ = w.Write([]byte(`[{"id": 30, "name": "Rule30"}, {"id": 31, "name": "Rule31"}]`))
+		default:
+			t.Errorf("unexpected request URI: %s", r.URL.RequestURI())
+		}
+	}))
+	defer ts.Close()
+
+	client := appdclient.New(resty.New().SetBaseURL(ts.URL))
+	targets, err := getAllHealthRules(context.Background(), &Controller{Name: "default", Client: client})
+	assert.NoError(t, err)
+
+	ids := make([]string, 0, len(targets))
+	for _, target := range targets {
+		ids = append(ids, target.Id)
+	}
+	assert.Equal(t, []string{"1-10", "3-30", "3-31"}, ids)
+}
+
 // Test Describe()
 func TestHealthRuleDiscovery_Describe(t *testing.T) {
 	var d healthRuleDiscovery
@@ -79,6 +119,20 @@ func TestHealthRuleDiscovery_Describe(t *testing.T) {
 	assert.Equal(t, "2m", *desc.Discover.CallInterval)
 }
 
+func TestHealthRuleDiscovery_DescribeWithConfiguredInterval(t *testing.T) {
+	config.Config.DiscoveryIntervalHealthRules = 5 * time.Minute
+	t.Cleanup(func() { config.Config.DiscoveryIntervalHealthRules = 0 })
+
+	desc := (&healthRuleDiscovery{}).Describe()
+	assert.Equal(t, "5m", *desc.Discover.CallInterval)
+}
+
+func TestGetCallInterval(t *testing.T) {
+	assert.Equal(t, "2m", getCallInterval(2*time.Minute, 30*time.Second))
+	assert.Equal(t, "90s", getCallInterval(time.Minute, 90*time.Second))
+	assert.Equal(t, "1h", getCallInterval(time.Minute, time.Hour))
+}
+
 // Test DescribeTarget()
 func TestHealthRuleDiscovery_DescribeTarget(t *testing.T) {
 	var d healthRuleDiscovery
@@ -89,8 +143,8 @@ func TestHealthRuleDiscovery_DescribeTarget(t *testing.T) {
 	assert.Equal(t, "AppDynamics Health Rules", td.Label.Other)
 	assert.NotNil(t, td.Category)
 	assert.Equal(t, "monitoring", *td.Category)
-	// should list exactly 5 columns
-	assert.Len(t, td.Table.Columns, 7)
+	// should list exactly 8 columns
+	assert.Len(t, td.Table.Columns, 8)
 	// first column should be the rule name
 	assert.Equal(t, HealthRuleAttribute+".name", td.Table.Columns[0].Attribute)
 	assert.Equal(t, discovery_kit_api.OrderByDirection("ASC"), td.Table.OrderBy[0].Direction)
@@ -108,6 +162,8 @@ func TestHealthRuleDiscovery_DescribeAttributes(t *testing.T) {
 		HealthRuleAttribute + AttributeAppID,
 		HealthRuleAttribute + AttributeAppName,
 		HealthRuleAttribute + AttributeOrigin,
+		HealthRuleAttribute + AttributeController,
+		HealthRuleAttribute + AttributeStale,
 	}
 	var got []string
 	for _, a := range attrs {
@@ -0,0 +1,224 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_sdk"
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/steadybit/extension-appdynamics/config"
+	"github.com/steadybit/extension-kit/extbuild"
+)
+
+type machineDiscovery struct {
+	controllers Controllers
+	last        lastTargets
+}
+
+const (
+	MachineAttribute = "appdynamics.machine"
+	MachineHostname  = ".hostname"
+	MachineOs        = ".os"
+	MachineCpuCount  = ".cpu-count"
+	// MachineTag prefixes the tags of a machine, e.g. "appdynamics.machine.tag.environment".
+	MachineTag = ".tag."
+
+	// hostTargetType is the target type of the hosts discovered by the Steadybit host extension.
+	hostTargetType     = "com.steadybit.extension_host.host"
+	hostnameAttribute  = "host.hostname"
+	machineOsProperty  = "OS|Kernel|Name"
+	machineCpuProperty = "Processor|Logical Core Count"
+)
+
+var (
+	_ discovery_kit_sdk.TargetDescriber          = (*machineDiscovery)(nil)
+	_ discovery_kit_sdk.AttributeDescriber       = (*machineDiscovery)(nil)
+	_ discovery_kit_sdk.EnrichmentRulesDescriber = (*machineDiscovery)(nil)
+)
+
+func NewMachineDiscovery(controllers Controllers) discovery_kit_sdk.TargetDiscovery {
+	discovery := &machineDiscovery{controllers: controllers}
+	return discovery_kit_sdk.NewCachedTargetDiscovery(discovery,
+		discovery_kit_sdk.WithRefreshTargetsNow(),
+		discovery_kit_sdk.WithRefreshTargetsInterval(context.Background(), getDiscoveryInterval(config.Config.DiscoveryIntervalMachines)),
+	)
+}
+
+func (d *machineDiscovery) Describe() discovery_kit_api.DiscoveryDescription {
+	return discovery_kit_api.DiscoveryDescription{
+		Id: machineTargetType,
+		Discover: discovery_kit_api.DescribingEndpointReferenceWithCallInterval{
+			CallInterval: new(getCallInterval(1*time.Minute, getDiscoveryInterval(config.Config.DiscoveryIntervalMachines))),
+		},
+	}
+}
+
+func (d *machineDiscovery) DescribeTarget() discovery_kit_api.TargetDescription {
+	return discovery_kit_api.TargetDescription{
+		Id:       machineTargetType,
+		Label:    discovery_kit_api.PluralLabel{One: "AppDynamics machine", Other: "AppDynamics machines"},
+		Category: new("monitoring"),
+		Version:  extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:     new(appDynamicsTargetIcon),
+		Table: discovery_kit_api.Table{
+			Columns: []discovery_kit_api.Column{
+				{Attribute: MachineAttribute + ".name"},
+				{Attribute: MachineAttribute + MachineHostname},
+				{Attribute: MachineAttribute + MachineOs},
+				{Attribute: MachineAttribute + MachineCpuCount},
+				{Attribute: MachineAttribute + AttributeController},
+			},
+			OrderBy: []discovery_kit_api.OrderBy{
+				{
+					Attribute: MachineAttribute + ".name",
+					Direction: "ASC",
+				},
+			},
+		},
+	}
+}
+
+func (d *machineDiscovery) DescribeAttributes() []discovery_kit_api.AttributeDescription {
+	return []discovery_kit_api.AttributeDescription{
+		{
+			Attribute: MachineAttribute + ".name",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Machine",
+				Other: "Machines",
+			},
+		}, {
+			Attribute: MachineAttribute + ".id",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "ID",
+				Other: "IDs",
+			},
+		}, {
+			Attribute: MachineAttribute + MachineHostname,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Hostname",
+				Other: "Hostnames",
+			},
+		}, {
+			Attribute: MachineAttribute + MachineOs,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Operating system",
+				Other: "Operating systems",
+			},
+		}, {
+			Attribute: MachineAttribute + MachineCpuCount,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "CPU count",
+				Other: "CPU counts",
+			},
+		}, {
+			Attribute: MachineAttribute + AttributeOrigin,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Machine controller url",
+				Other: "Machine controller urls",
+			},
+		}, {
+			Attribute: MachineAttribute + AttributeController,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Machine controller",
+				Other: "Machine controllers",
+			},
+		}, {
+			Attribute: MachineAttribute + AttributeStale,
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Stale",
+				Other: "Stale",
+			},
+		},
+	}
+}
+
+// DescribeEnrichmentRules adds the attributes of the machines to the hosts discovered by the Steadybit host extension
+// with the same hostname.
+func (d *machineDiscovery) DescribeEnrichmentRules() []discovery_kit_api.TargetEnrichmentRule {
+	return []discovery_kit_api.TargetEnrichmentRule{
+		{
+			Id:      machineTargetType + "-to-host",
+			Version: extbuild.GetSemverVersionStringOrUnknown(),
+			Src: discovery_kit_api.SourceOrDestination{
+				Type:     machineTargetType,
+				Selector: map[string]string{hostnameAttribute: "${dest." + hostnameAttribute + "}"},
+			},
+			Dest: discovery_kit_api.SourceOrDestination{
+				Type:     hostTargetType,
+				Selector: map[string]string{hostnameAttribute: "${src." + hostnameAttribute + "}"},
+			},
+			Attributes: []discovery_kit_api.Attribute{
+				{Matcher: discovery_kit_api.StartsWith, Name: MachineAttribute + "."},
+			},
+		},
+	}
+}
+
+func (d *machineDiscovery) DiscoverTargets(ctx context.Context) ([]discovery_kit_api.Target, error) {
+	result, err := discoverTargets(ctx, d.controllers, &d.last, machineTargetType, MachineAttribute, getAllMachines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover machines: %w", err)
+	}
+	return result, nil
+}
+
+func getAllMachines(ctx context.Context, controller *Controller) ([]discovery_kit_api.Target, error) {
+	machines, err := controller.Client.ListMachines(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve machines: %w", err)
+	}
+	log.Trace().Msgf("AppDynamics response: %v", machines)
+
+	result := make([]discovery_kit_api.Target, 0, len(machines))
+	for _, machine := range machines {
+		machineId := strconv.FormatInt(machine.ID, 10)
+		hostname := machine.HostID
+		if hostname == "" {
+			hostname = machine.Name
+		}
+		attributes := map[string][]string{
+			MachineAttribute + ".name":             {machine.Name},
+			MachineAttribute + ".id":               {machineId},
+			MachineAttribute + MachineHostname:     {hostname},
+			MachineAttribute + AttributeOrigin:     {controller.Client.BaseUrl()},
+			MachineAttribute + AttributeController: {controller.Name},
+			hostnameAttribute:                      {hostname},
+		}
+		if os := machine.Properties[machineOsProperty]; os != "" {
+			attributes[MachineAttribute+MachineOs] = []string{os}
+		}
+		if cpuCount := getMachineCpuCount(machine); cpuCount > 0 {
+			attributes[MachineAttribute+MachineCpuCount] = []string{strconv.Itoa(cpuCount)}
+		}
+		for key, values := range machine.Tags {
+			attributes[MachineAttribute+MachineTag+key] = values
+		}
+		result = append(result, discovery_kit_api.Target{
+			Id:         controller.getTargetId("machine-" + machineId),
+			TargetType: machineTargetType,
+			Label:      machine.Name,
+			Attributes: attributes,
+		})
+	}
+	return result, nil
+}
+
+// getMachineCpuCount returns the number of logical processors, as reported in the machine's properties or else summed
+// up over its CPUs.
+func getMachineCpuCount(machine appdclient.Machine) int {
+	if cpuCount, err := strconv.Atoi(machine.Properties[machineCpuProperty]); err == nil {
+		return cpuCount
+	}
+	cpuCount := 0
+	for _, cpu := range machine.Cpus {
+		cpuCount += cpu.LogicalProcessors
+	}
+	return cpuCount
+}
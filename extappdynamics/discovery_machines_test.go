@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMachineDiscovery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/controller/sim/v2/user/machines" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"id":1,"name":"web-1","hostId":"web-1.example.com","simEnabled":true,
+			 "properties":{"OS|Kernel|Name":"Linux","Processor|Logical Core Count":"8"},
+			 "tags":{"environment":["prod"]}},
+			{"id":2,"name":"db-1","cpus":[{"cores":2,"logicalProcessors":4},{"cores":2,"logicalProcessors":4}]}
+		]`))
+	}))
+	defer ts.Close()
+
+	discovery := &machineDiscovery{controllers: Controllers{{Name: "default", Client: newTestClient(ts)}}}
+	targets, err := discovery.DiscoverTargets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+
+	web := targets[0]
+	assert.Equal(t, machineTargetType, web.TargetType)
+	assert.Equal(t, "web-1", web.Label)
+	assert.Equal(t, []string{"web-1.example.com"}, web.Attributes[MachineAttribute+MachineHostname])
+	assert.Equal(t, []string{"web-1.example.com"}, web.Attributes[hostnameAttribute])
+	assert.Equal(t, []string{"Linux"}, web.Attributes[MachineAttribute+MachineOs])
+	assert.Equal(t, []string{"8"}, web.Attributes[MachineAttribute+MachineCpuCount])
+	assert.Equal(t, []string{"prod"}, web.Attributes[MachineAttribute+MachineTag+"environment"])
+
+	db := targets[1]
+	assert.Equal(t, []string{"db-1"}, db.Attributes[MachineAttribute+MachineHostname])
+	assert.Equal(t, []string{"8"}, db.Attributes[MachineAttribute+MachineCpuCount])
+	assert.NotContains(t, db.Attributes, MachineAttribute+MachineOs)
+}
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/steadybit/extension-appdynamics/config"
+)
+
+// AttributeStale marks targets of a controller that failed during the latest discovery. They are the targets of the
+// last successful discovery of the controller.
+const AttributeStale = ".stale"
+
+// lastTargets remembers the targets last discovered from each controller. The zero value is ready to use.
+type lastTargets struct {
+	mutex   sync.Mutex
+	targets map[string][]discovery_kit_api.Target
+}
+
+func (l *lastTargets) get(controllerName string) ([]discovery_kit_api.Target, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	targets, ok := l.targets[controllerName]
+	return targets, ok
+}
+
+func (l *lastTargets) set(controllerName string, targets []discovery_kit_api.Target) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.targets == nil {
+		l.targets = make(map[string][]discovery_kit_api.Target)
+	}
+	l.targets[controllerName] = targets
+}
+
+// discoverTargets discovers the targets of all controllers. The last targets of a failing controller are reported as
+// stale, so that they don't vanish because of a temporary failure. An error is returned if no targets are left due to
+// failures, or, with config.Specification.DiscoveryFailIfEmpty, if no targets were discovered at all. The cached
+// discovery then keeps reporting its last result.
+func discoverTargets(ctx context.Context, controllers Controllers, last *lastTargets, targetType string, attributePrefix string, discover func(context.Context, *Controller) ([]discovery_kit_api.Target, error)) ([]discovery_kit_api.Target, error) {
+	result := make([]discovery_kit_api.Target, 0, 1000)
+	var errs []error
+	for _, controller := range controllers {
+		targets, err := discover(ctx, controller)
+		if err == nil {
+			last.set(controller.Name, targets)
+			result = append(result, targets...)
+			continue
+		}
+		discoveryFailures.WithLabelValues(targetType, controller.Name).Inc()
+		if previous, ok := last.get(controller.Name); ok {
+			log.Warn().Err(err).Msgf("Failed to discover targets of AppDynamics controller '%s', reporting the targets of its last successful discovery as stale.", controller.Name)
+			result = append(result, markStale(previous, attributePrefix)...)
+			continue
+		}
+		errs = append(errs, fmt.Errorf("AppDynamics controller '%s': %w", controller.Name, err))
+	}
+
+	if len(errs) > 0 {
+		if len(result) == 0 {
+			return nil, errors.Join(errs...)
+		}
+		log.Err(errors.Join(errs...)).Msg("Failed to discover targets of some AppDynamics controllers.")
+	}
+	if len(result) == 0 && config.Config.DiscoveryFailIfEmpty {
+		return nil, errors.New("no targets discovered, keeping the last result")
+	}
+	discoveredTargets.WithLabelValues(targetType).Set(float64(len(result)))
+	return result, nil
+}
+
+func markStale(targets []discovery_kit_api.Target, attributePrefix string) []discovery_kit_api.Target {
+	stale := make([]discovery_kit_api.Target, 0, len(targets))
+	for _, target := range targets {
+		attributes := maps.Clone(target.Attributes)
+		attributes[attributePrefix+AttributeStale] = []string{"true"}
+		target.Attributes = attributes
+		stale = append(stale, target)
+	}
+	return stale
+}
+
+// discoverApplicationTargets discovers the targets of all applications of the controller, e.g. their health rules, up to
+// concurrency applications at a time. Applications whose targets can't be listed, e.g. due to missing permissions, are
+// skipped, the targets of all other applications are still reported. It fails if the applications can't be listed or the
+// targets of none of them.
+func discoverApplicationTargets(ctx context.Context, controller *Controller, kind string, concurrency int, discover func(context.Context, *Controller, appdclient.Application) ([]discovery_kit_api.Target, error)) ([]discovery_kit_api.Target, error) {
+	applications, err := controller.listApplications(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve applications: %w", err)
+	}
+	log.Trace().Msgf("AppDynamics response: %v", applications)
+
+	targetsByApplication := make([][]discovery_kit_api.Target, len(applications))
+	errs := make([]error, len(applications))
+	workers := make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+	for i, app := range applications {
+		workers <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-workers }()
+			targetsByApplication[i], errs[i] = discover(ctx, controller, app)
+		})
+	}
+	wg.Wait()
+
+	result := make([]discovery_kit_api.Target, 0, 1000)
+	failed := 0
+	for i, targets := range targetsByApplication {
+		if errs[i] != nil {
+			log.Warn().Err(errs[i]).Msgf("Failed to retrieve %s from AppDynamics controller '%s' with application %d, skipping the application.", kind, controller.Name, applications[i].ID)
+			failed++
+			continue
+		}
+		result = append(result, targets...)
+	}
+	if failed > 0 && failed == len(applications) {
+		return nil, fmt.Errorf("failed to retrieve %s of all applications: %w", kind, errors.Join(errs...))
+	}
+	return result, nil
+}
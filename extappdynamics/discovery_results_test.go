@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/steadybit/extension-appdynamics/config"
+	"github.com/stretchr/testify/require"
+)
+
+type flakyApplicationsClient struct {
+	AppDynamicsClient
+	applications []appdclient.Application
+	err          error
+}
+
+func (c *flakyApplicationsClient) BaseUrl() string {
+	return "https://acme.saas.appdynamics.com"
+}
+
+func (c *flakyApplicationsClient) ListApplications(context.Context) ([]appdclient.Application, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.applications, nil
+}
+
+func TestApplicationDiscovery_ReportsLastTargetsOfFailingControllerAsStale(t *testing.T) {
+	saas := &flakyApplicationsClient{applications: []appdclient.Application{{ID: 1, Name: "checkout"}}}
+	onprem := &flakyApplicationsClient{applications: []appdclient.Application{{ID: 2, Name: "ledger"}}}
+	discovery := &applicationDiscovery{controllers: Controllers{
+		{Name: config.DefaultControllerName, Client: saas},
+		{Name: "onprem", Client: onprem},
+	}}
+
+	targets, err := discovery.DiscoverTargets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	require.NotContains(t, targets[1].Attributes, AppAttribute+AttributeStale)
+
+	failuresBefore := testutil.ToFloat64(discoveryFailures.WithLabelValues(applicationTargetType, "onprem"))
+	onprem.err = errors.New("connection refused")
+	targets, err = discovery.DiscoverTargets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	require.Equal(t, 2.0, testutil.ToFloat64(discoveredTargets.WithLabelValues(applicationTargetType)))
+	require.Equal(t, failuresBefore+1, testutil.ToFloat64(discoveryFailures.WithLabelValues(applicationTargetType, "onprem")))
+	require.NotContains(t, targets[0].Attributes, AppAttribute+AttributeStale)
+	require.Equal(t, "onprem/2", targets[1].Id)
+	require.Equal(t, []string{"true"}, targets[1].Attributes[AppAttribute+AttributeStale])
+
+	onprem.err = nil
+	targets, err = discovery.DiscoverTargets(context.Background())
+	require.NoError(t, err)
+	require.NotContains(t, targets[1].Attributes, AppAttribute+AttributeStale)
+}
+
+func TestApplicationDiscovery_FailsWithoutTargets(t *testing.T) {
+	client := &flakyApplicationsClient{err: errors.New("connection refused")}
+	discovery := &applicationDiscovery{controllers: Controllers{{Name: config.DefaultControllerName, Client: client}}}
+
+	_, err := discovery.DiscoverTargets(context.Background())
+	require.ErrorContains(t, err, "connection refused")
+
+	client.err = nil
+	targets, err := discovery.DiscoverTargets(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, targets)
+
+	config.Config.DiscoveryFailIfEmpty = true
+	t.Cleanup(func() { config.Config.DiscoveryFailIfEmpty = false })
+	_, err = discovery.DiscoverTargets(context.Background())
+	require.ErrorContains(t, err, "no targets discovered")
+}
@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	pollOutcomeViolations       = "violations"
+	pollOutcomeNoViolations     = "no_violations"
+	pollOutcomeToleratedFailure = "tolerated_failure"
+	pollOutcomeFailure          = "failure"
+)
+
+var (
+	discoveredTargets = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "steadybit_appdynamics_discovered_targets",
+		Help: "Number of targets reported by the latest successful discovery, by target type.",
+	}, []string{"target_type"})
+	discoveryFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "steadybit_appdynamics_discovery_failures_total",
+		Help: "Number of discoveries that failed for a controller, by target type.",
+	}, []string{"target_type", "controller"})
+	activeActionSuppressions = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "steadybit_appdynamics_action_suppressions_active",
+		Help: "Number of action suppressions created by the extension that were not deleted yet.",
+	})
+	healthRuleCheckPolls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "steadybit_appdynamics_health_rule_check_polls_total",
+		Help: "Number of health rule violation polls of the health rule check, by outcome.",
+	}, []string{"outcome"})
+)
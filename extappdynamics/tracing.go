@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"strings"
+
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/steadybit/extension-appdynamics/extappdynamics")
+
+// ExecutionTrace identifies the experiment execution and step an action belongs to. It is part of the action states, so
+// that the spans of all calls of an action can be correlated with the experiment run.
+type ExecutionTrace struct {
+	ExperimentKey string
+	ExecutionId   int
+	StepId        string
+}
+
+func newExecutionTrace(request action_kit_api.PrepareActionRequestBody) ExecutionTrace {
+	executionTrace := ExecutionTrace{StepId: request.ExecutionId.String()}
+	if request.ExecutionContext != nil {
+		if request.ExecutionContext.ExperimentKey != nil {
+			executionTrace.ExperimentKey = *request.ExecutionContext.ExperimentKey
+		}
+		if request.ExecutionContext.ExecutionId != nil {
+			executionTrace.ExecutionId = *request.ExecutionContext.ExecutionId
+		}
+	}
+	return executionTrace
+}
+
+// auditInitiator identifies the execution as initiator of the mutating requests of an action. Action requests don't
+// carry the team, it is derived from the experiment key, e.g. "ADM" for "ADM-1".
+func (t ExecutionTrace) auditInitiator() appdclient.AuditInitiator {
+	team := ""
+	if i := strings.LastIndex(t.ExperimentKey, "-"); i > 0 {
+		team = t.ExperimentKey[:i]
+	}
+	return appdclient.AuditInitiator{Team: team, ExperimentKey: t.ExperimentKey, ExecutionId: t.ExecutionId, StepId: t.StepId}
+}
+
+func (t ExecutionTrace) attributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("steadybit.experiment.key", t.ExperimentKey),
+		attribute.Int("steadybit.execution.id", t.ExecutionId),
+		attribute.String("steadybit.step.id", t.StepId),
+	}
+}
+
+// startActionSpan starts the span of an action call, e.g. "health-rule-check.status".
+func startActionSpan(ctx context.Context, name string, executionTrace ExecutionTrace, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(append(executionTrace.attributes(), attributes...)...))
+}
+
+// endActionSpan ends the span of an action call, recording the error the call failed with or the error it reported.
+func endActionSpan(span trace.Span, actionError *action_kit_api.ActionKitError, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if actionError != nil {
+		span.SetAttributes(attribute.String("steadybit.action.error", actionError.Title))
+	}
+	span.End()
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extappdynamics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHealthRuleCheck_StatusIsTraced(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+
+	action := &HealthRuleStateCheckAction{controllers: Controllers{
+		{Name: "default", Client: appdclient.New(resty.New().SetBaseURL(ts.URL), appdclient.WithName("default"))},
+	}}
+	state := HealthRuleCheckState{
+		HealthRuleId:          "7",
+		HealthRuleName:        "foo",
+		HealthRuleApplication: "42",
+		Controller:            "default",
+		End:                   time.Now().Add(time.Minute),
+		StateCheckMode:        StateCheckModeAllTheTime,
+		Trace:                 ExecutionTrace{ExperimentKey: "ADM-1", ExecutionId: 12, StepId: "step-1"},
+	}
+	_, err := action.Status(context.Background(), &state)
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	request, status := spans[0], spans[1]
+	require.Equal(t, "health-rule-check.status", status.Name)
+	for _, expected := range []attribute.KeyValue{
+		attribute.String("steadybit.experiment.key", "ADM-1"),
+		attribute.Int("steadybit.execution.id", 12),
+		attribute.String("steadybit.step.id", "step-1"),
+		attribute.String("appdynamics.application.id", "42"),
+		attribute.String("appdynamics.health-rule.id", "7"),
+	} {
+		require.Contains(t, status.Attributes, expected)
+	}
+	require.Equal(t, "GET /controller/rest/applications/{id}/problems/healthrule-violations", request.Name)
+	require.Equal(t, status.SpanContext.SpanID(), request.Parent.SpanID())
+}
+
+func TestExecutionTrace_AuditInitiatorDerivesTeam(t *testing.T) {
+	initiator := ExecutionTrace{ExperimentKey: "PLATFORM-OPS-17", ExecutionId: 3, StepId: "step-1"}.auditInitiator()
+	require.Equal(t, appdclient.AuditInitiator{Team: "PLATFORM-OPS", ExperimentKey: "PLATFORM-OPS-17", ExecutionId: 3, StepId: "step-1"}, initiator)
+	require.Empty(t, ExecutionTrace{}.auditInitiator().Team)
+}
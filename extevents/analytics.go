@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extevents
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/event-kit/go/event_kit_api"
+	"github.com/steadybit/extension-appdynamics/config"
+)
+
+const analyticsDateFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// AnalyticsClient publishes records to the AppDynamics Analytics Events API, implemented by appdclient.Client.
+type AnalyticsClient interface {
+	AnalyticsSchemaExists(ctx context.Context, name string) (bool, error)
+	CreateAnalyticsSchema(ctx context.Context, name string, schema map[string]string) error
+	PublishAnalyticsEvents(ctx context.Context, schemaName string, records []map[string]any) error
+}
+
+// analyticsSchema describes the typed fields of the experiment lifecycle records. Changing existing fields requires
+// a new schema name, as the Analytics Events API only allows adding fields to an existing schema.
+var analyticsSchema = map[string]string{
+	"event_name":       "string",
+	"environment":      "string",
+	"tenant":           "string",
+	"team":             "string",
+	"execution_id":     "integer",
+	"experiment_key":   "string",
+	"experiment_name":  "string",
+	"execution_state":  "string",
+	"step_id":          "string",
+	"step_name":        "string",
+	"step_action_id":   "string",
+	"step_action_kind": "string",
+	"step_state":       "string",
+	"target_name":      "string",
+	"target_type":      "string",
+	"target_state":     "string",
+	"started_time":     "date",
+	"ended_time":       "date",
+	"duration_ms":      "integer",
+	"target_count":     "integer",
+}
+
+var (
+	analyticsSchemaMutex sync.Mutex
+	analyticsSchemaReady bool
+	// attackedTargets counts the attacked targets per execution id until the execution completes.
+	attackedTargets = newExecutionState[int64]()
+)
+
+// analyticsEventSink publishes events as typed records to the AppDynamics Analytics Events API.
+type analyticsEventSink struct {
+	client AnalyticsClient
+}
+
+func NewAnalyticsEventSink(client AnalyticsClient) EventSink {
+	return &analyticsEventSink{client: client}
+}
+
+func (s *analyticsEventSink) Name() string {
+	return AnalyticsEventSinkName
+}
+
+func (s *analyticsEventSink) Post(ctx context.Context, event event_kit_api.EventRequestBody, _ []KeyValue) error {
+	return handlePostAnalyticsEvent(ctx, s.client, event)
+}
+
+func handlePostAnalyticsEvent(ctx context.Context, client AnalyticsClient, event event_kit_api.EventRequestBody) error {
+	if err := ensureAnalyticsSchema(ctx, client); err != nil {
+		return fmt.Errorf("failed to prepare analytics schema '%s': %w", config.Config.AnalyticsSchemaName, err)
+	}
+
+	if err := client.PublishAnalyticsEvents(ctx, config.Config.AnalyticsSchemaName, []map[string]any{toAnalyticsRecord(event)}); err != nil {
+		return fmt.Errorf("failed to publish analytics event: %w", err)
+	}
+	return nil
+}
+
+// ensureAnalyticsSchema creates the analytics schema on first use. A failed attempt is retried with the next event.
+func ensureAnalyticsSchema(ctx context.Context, client AnalyticsClient) error {
+	analyticsSchemaMutex.Lock()
+	defer analyticsSchemaMutex.Unlock()
+	if analyticsSchemaReady {
+		return nil
+	}
+
+	exists, err := client.AnalyticsSchemaExists(ctx, config.Config.AnalyticsSchemaName)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the schema: %w", err)
+	}
+	if exists {
+		log.Debug().Msgf("Analytics schema '%s' already exists.", config.Config.AnalyticsSchemaName)
+	} else {
+		if err := client.CreateAnalyticsSchema(ctx, config.Config.AnalyticsSchemaName, analyticsSchema); err != nil {
+			return fmt.Errorf("failed to create the schema: %w", err)
+		}
+		log.Info().Msgf("Created analytics schema '%s'.", config.Config.AnalyticsSchemaName)
+	}
+
+	analyticsSchemaReady = true
+	return nil
+}
+
+func toAnalyticsRecord(event event_kit_api.EventRequestBody) map[string]any {
+	eventTime := event.EventTime
+	if eventTime.IsZero() {
+		eventTime = time.Now()
+	}
+	record := map[string]any{
+		"eventTimestamp": eventTime.Format(analyticsDateFormat),
+		"event_name":     event.EventName,
+		"tenant":         event.Tenant.Key,
+	}
+	if event.Environment != nil {
+		record["environment"] = event.Environment.Name
+	}
+	if event.Team != nil {
+		record["team"] = event.Team.Key
+	}
+
+	if execution := event.ExperimentExecution; execution != nil {
+		record["execution_id"] = int64(execution.ExecutionId)
+		record["experiment_key"] = execution.ExperimentKey
+		record["experiment_name"] = execution.Name
+		record["execution_state"] = string(execution.State)
+		setAnalyticsTimes(record, &execution.StartedTime, execution.EndedTime)
+		if execution.EndedTime != nil {
+			record["target_count"] = takeAttackedTargets(fmt.Sprintf("%.0f", execution.ExecutionId))
+		}
+	}
+
+	if step := event.ExperimentStepExecution; step != nil {
+		record["execution_id"] = int64(step.ExecutionId)
+		record["experiment_key"] = step.ExperimentKey
+		record["step_id"] = step.Id.String()
+		record["step_state"] = string(step.State)
+		if step.ActionId != nil {
+			record["step_action_id"] = *step.ActionId
+		}
+		if step.ActionName != nil {
+			record["step_name"] = *step.ActionName
+		}
+		if step.ActionKind != nil {
+			record["step_action_kind"] = string(*step.ActionKind)
+		}
+		setAnalyticsTimes(record, step.StartedTime, step.EndedTime)
+	}
+
+	if target := event.ExperimentStepTargetExecution; target != nil {
+		record["execution_id"] = int64(target.ExecutionId)
+		record["experiment_key"] = target.ExperimentKey
+		record["step_id"] = target.StepExecutionId.String()
+		record["target_name"] = target.TargetName
+		record["target_type"] = target.TargetType
+		record["target_state"] = string(target.State)
+		setAnalyticsTimes(record, target.StartedTime, target.EndedTime)
+		if target.EndedTime == nil {
+			record["target_count"] = incrementAttackedTargets(fmt.Sprintf("%.0f", target.ExecutionId))
+		}
+	}
+
+	return record
+}
+
+func setAnalyticsTimes(record map[string]any, started *time.Time, ended *time.Time) {
+	if started != nil && !started.IsZero() {
+		record["started_time"] = started.Format(analyticsDateFormat)
+	}
+	if ended != nil && !ended.IsZero() {
+		record["ended_time"] = ended.Format(analyticsDateFormat)
+		if started != nil && !started.IsZero() {
+			record["duration_ms"] = ended.Sub(*started).Milliseconds()
+		}
+	}
+}
+
+func incrementAttackedTargets(executionId string) int64 {
+	return attackedTargets.update(executionId, time.Now(), func(count int64) int64 { return count + 1 })
+}
+
+// takeAttackedTargets returns the number of attacked targets of a completed execution and forgets about it.
+func takeAttackedTargets(executionId string) int64 {
+	count, _ := attackedTargets.take(executionId)
+	return count
+}
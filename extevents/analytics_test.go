@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extevents
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
+	"github.com/steadybit/event-kit/go/event_kit_api"
+	"github.com/steadybit/extension-appdynamics/appdclient"
+	"github.com/steadybit/extension-appdynamics/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetAnalyticsState(t *testing.T) {
+	analyticsSchemaReady = false
+	attackedTargets = newExecutionState[int64]()
+	old := config.Config
+	config.Config.AnalyticsSchemaName = "steadybit_experiments"
+	t.Cleanup(func() { config.Config = old })
+}
+
+func TestHandlePostAnalyticsEvent_CreatesSchemaOnFirstUse(t *testing.T) {
+	resetAnalyticsState(t)
+	var requests []string
+	var schema map[string]map[string]string
+	var published []map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		assert.Equal(t, appdclient.AnalyticsContentType, r.Header.Get("Content-Type"))
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/events/schema/steadybit_experiments":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost && r.URL.Path == "/events/schema/steadybit_experiments":
+			_ = json.NewDecoder(r.Body).Decode(&schema)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPost && r.URL.Path == "/events/publish/steadybit_experiments":
+			_ = json.NewDecoder(r.Body).Decode(&published)
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	client := appdclient.New(resty.New().SetBaseURL(ts.URL))
+
+	event := event_kit_api.EventRequestBody{EventName: "experiment.execution.created", Tenant: event_kit_api.Tenant{Key: "t"}}
+	require.NoError(t, handlePostAnalyticsEvent(context.Background(), client, event))
+	require.NoError(t, handlePostAnalyticsEvent(context.Background(), client, event))
+
+	assert.Equal(t, []string{
+		"GET /events/schema/steadybit_experiments",
+		"POST /events/schema/steadybit_experiments",
+		"POST /events/publish/steadybit_experiments",
+		"POST /events/publish/steadybit_experiments",
+	}, requests)
+	assert.Equal(t, "integer", schema["schema"]["duration_ms"])
+	require.Len(t, published, 1)
+	assert.Equal(t, "experiment.execution.created", published[0]["event_name"])
+}
+
+func TestHandlePostAnalyticsEvent_SchemaErrorSkipsPublish(t *testing.T) {
+	resetAnalyticsState(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/events/schema/steadybit_experiments" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	err := handlePostAnalyticsEvent(context.Background(), appdclient.New(resty.New().SetBaseURL(ts.URL)), event_kit_api.EventRequestBody{})
+	assert.Error(t, err)
+	assert.False(t, analyticsSchemaReady)
+}
+
+func TestToAnalyticsRecord_TypedFields(t *testing.T) {
+	resetAnalyticsState(t)
+	started := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	ended := started.Add(90 * time.Second)
+	stepId := uuid.New()
+
+	target := toAnalyticsRecord(event_kit_api.EventRequestBody{
+		EventName: "experiment.execution.target-started",
+		ExperimentStepTargetExecution: &event_kit_api.ExperimentStepTargetExecution{
+			ExecutionId:     42,
+			ExperimentKey:   "ADM-1",
+			StepExecutionId: stepId,
+			TargetName:      "pod-1",
+			State:           event_kit_api.Created,
+			StartedTime:     &started,
+		},
+	})
+	assert.Equal(t, int64(42), target["execution_id"])
+	assert.Equal(t, stepId.String(), target["step_id"])
+	assert.Equal(t, int64(1), target["target_count"])
+
+	completed := toAnalyticsRecord(event_kit_api.EventRequestBody{
+		EventName: "experiment.execution.completed",
+		Team:      &event_kit_api.Team{Key: "ADM"},
+		ExperimentExecution: &event_kit_api.ExperimentExecution{
+			ExecutionId:   42,
+			ExperimentKey: "ADM-1",
+			State:         "completed",
+			StartedTime:   started,
+			EndedTime:     &ended,
+		},
+	})
+	assert.Equal(t, "completed", completed["execution_state"])
+	assert.Equal(t, int64(90_000), completed["duration_ms"])
+	assert.Equal(t, int64(1), completed["target_count"])
+	assert.Equal(t, "ADM", completed["team"])
+}
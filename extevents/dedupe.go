@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extevents
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/steadybit/event-kit/go/event_kit_api"
+	"github.com/steadybit/extension-appdynamics/config"
+)
+
+var deduplicatedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "steadybit_appdynamics_events_deduplicated_total",
+	Help: "Number of event callbacks that were skipped because the same event was already handled within the deduplication window.",
+}, []string{"event_name"})
+
+// handledEvents remembers the idempotency keys of recently handled events, so that callbacks retried by the platform
+// are not posted again.
+var handledEvents = &eventDeduplicator{seen: map[string]time.Time{}}
+
+type eventDeduplicator struct {
+	mutex sync.Mutex
+	seen  map[string]time.Time
+}
+
+// getIdempotencyKey identifies an event by its name and the execution, step and target it refers to.
+func getIdempotencyKey(event event_kit_api.EventRequestBody) string {
+	execution, _ := getEventExecution(event)
+	return fmt.Sprintf("%s|%.0f|%s|%s", event.EventName, execution.executionId, execution.stepId, execution.targetId)
+}
+
+// claim returns false if the key was already claimed within the window. Otherwise, the key is claimed until the window
+// elapses or it is released again.
+func (d *eventDeduplicator) claim(key string, window time.Duration, now time.Time) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for k, claimed := range d.seen {
+		if now.Sub(claimed) >= window {
+			delete(d.seen, k)
+		}
+	}
+
+	if _, ok := d.seen[key]; ok {
+		return false
+	}
+	d.seen[key] = now
+	return true
+}
+
+func (d *eventDeduplicator) release(key string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	delete(d.seen, key)
+}
+
+// claimEvent returns false if the event is a duplicate of an event handled within the configured window. It returns a
+// function that releases the claim again, e.g. if handling the event failed and a retry should be processed.
+func claimEvent(event event_kit_api.EventRequestBody) (bool, func()) {
+	window := config.Config.EventDeduplicationWindow
+	if window <= 0 {
+		return true, func() {}
+	}
+	key := getIdempotencyKey(event)
+	if !handledEvents.claim(key, window, time.Now()) {
+		deduplicatedEvents.WithLabelValues(event.EventName).Inc()
+		return false, nil
+	}
+	return true, func() { handledEvents.release(key) }
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extevents
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/steadybit/event-kit/go/event_kit_api"
+	"github.com/steadybit/extension-appdynamics/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetIdempotencyKey_DistinguishesTargets(t *testing.T) {
+	stepId := uuid.New()
+	first := event_kit_api.EventRequestBody{
+		EventName:                     "experiment.execution.target-started",
+		ExperimentStepTargetExecution: &event_kit_api.ExperimentStepTargetExecution{ExecutionId: 42, StepExecutionId: stepId, Id: uuid.New()},
+	}
+	second := event_kit_api.EventRequestBody{
+		EventName:                     "experiment.execution.target-started",
+		ExperimentStepTargetExecution: &event_kit_api.ExperimentStepTargetExecution{ExecutionId: 42, StepExecutionId: stepId, Id: uuid.New()},
+	}
+
+	assert.Equal(t, getIdempotencyKey(first), getIdempotencyKey(first))
+	assert.NotEqual(t, getIdempotencyKey(first), getIdempotencyKey(second))
+}
+
+func TestEventDeduplicator_ClaimExpiresAfterWindow(t *testing.T) {
+	d := &eventDeduplicator{seen: map[string]time.Time{}}
+	now := time.Now()
+
+	assert.True(t, d.claim("a", time.Minute, now))
+	assert.False(t, d.claim("a", time.Minute, now.Add(30*time.Second)))
+	assert.True(t, d.claim("a", time.Minute, now.Add(61*time.Second)))
+
+	d.release("a")
+	assert.True(t, d.claim("a", time.Minute, now.Add(62*time.Second)))
+}
+
+func TestHandle_SkipsRetriedCallbacks(t *testing.T) {
+	oldWindow := config.Config.EventDeduplicationWindow
+	t.Cleanup(func() { config.Config.EventDeduplicationWindow = oldWindow })
+	config.Config.EventDeduplicationWindow = time.Minute
+	handledEvents = &eventDeduplicator{seen: map[string]time.Time{}}
+
+	calls := 0
+	handler := handle(func(event event_kit_api.EventRequestBody) ([]KeyValue, error) {
+		calls++
+		return nil, nil
+	})
+	body := []byte(`{"eventName":"experiment.execution.dedupe-test","experimentExecution":{"executionId":42,"experimentKey":"ADM-1"}}`)
+
+	before := testutil.ToFloat64(deduplicatedEvents.WithLabelValues("experiment.execution.dedupe-test"))
+	for range 3 {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/events/experiment-started", nil), body)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, before+2, testutil.ToFloat64(deduplicatedEvents.WithLabelValues("experiment.execution.dedupe-test")))
+}
+
+func TestHandle_RedeliversEventsNoSinkPosted(t *testing.T) {
+	oldWindow := config.Config.EventDeduplicationWindow
+	t.Cleanup(func() { config.Config.EventDeduplicationWindow = oldWindow })
+	config.Config.EventDeduplicationWindow = time.Minute
+	handledEvents = &eventDeduplicator{seen: map[string]time.Time{}}
+	ClearEventSinks()
+	t.Cleanup(ClearEventSinks)
+	sink := &recordingSink{name: "flaky", err: errors.New("boom")}
+	RegisterEventSink(sink)
+
+	handler := handle(func(event event_kit_api.EventRequestBody) ([]KeyValue, error) {
+		return []KeyValue{{Key: "summary", Value: "test"}}, nil
+	})
+	body := []byte(`{"eventName":"experiment.execution.redeliver-test","experimentExecution":{"executionId":42,"experimentKey":"ADM-1"}}`)
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/events/experiment-started", nil), body)
+	assert.NotEqual(t, http.StatusOK, w.Code)
+
+	sink.err = nil
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/events/experiment-started", nil), body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"experiment.execution.redeliver-test", "experiment.execution.redeliver-test"}, sink.events)
+}
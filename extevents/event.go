@@ -7,14 +7,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/go-resty/resty/v2"
 	"github.com/rs/zerolog/log"
 	"github.com/steadybit/event-kit/go/event_kit_api"
+	"github.com/steadybit/extension-appdynamics/appdclient"
 	"github.com/steadybit/extension-appdynamics/config"
 	extension_kit "github.com/steadybit/extension-kit"
 	"github.com/steadybit/extension-kit/exthttp"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -38,6 +39,9 @@ var specialKeysOrder = []string{
 
 var (
 	stepExecutions = sync.Map{}
+	// correlationLinks holds the link to the start event of each running execution, keyed by execution key, until the
+	// execution completes or the link expires
+	correlationLinks = newExecutionState[string]()
 )
 
 type KeyValue struct {
@@ -45,7 +49,11 @@ type KeyValue struct {
 	Value string
 }
 
-var RestyClient *resty.Client
+// CustomEventClient posts custom events to an AppDynamics controller, implemented by appdclient.Client.
+type CustomEventClient interface {
+	BaseUrl() string
+	PostEvent(ctx context.Context, applicationId string, query string) (string, error)
+}
 
 type eventHandler func(event event_kit_api.EventRequestBody) ([]KeyValue, error)
 
@@ -58,11 +66,19 @@ func handle(handler eventHandler) func(w http.ResponseWriter, r *http.Request, b
 			return
 		}
 
-		if request, err := handler(event); err == nil {
-			if request != nil {
-				handlePostEvent(r.Context(), RestyClient, request)
-			}
-		} else {
+		claimed, release := claimEvent(event)
+		if !claimed {
+			log.Debug().Msgf("Skipping duplicate event %s", event.EventName)
+			exthttp.WriteBody(w, "{}")
+			return
+		}
+
+		request, err := handler(event)
+		if err == nil && request != nil {
+			err = dispatch(r.Context(), event, request)
+		}
+		if err != nil {
+			release()
 			exthttp.WriteError(w, extension_kit.ToError(err.Error(), err))
 			return
 		}
@@ -73,6 +89,7 @@ func handle(handler eventHandler) func(w http.ResponseWriter, r *http.Request, b
 
 func onExperiment(event event_kit_api.EventRequestBody) ([]KeyValue, error) {
 	tags := getEventBaseTags(event)
+	tags = append(tags, getCorrelationTags(event)...)
 	tags = append(tags, getExecutionTags(event)...)
 
 	return tags, nil
@@ -88,11 +105,22 @@ func onExperimentCompleted(event event_kit_api.EventRequestBody) ([]KeyValue, er
 		return true
 	})
 
-	return onExperiment(event)
+	tags, err := onExperiment(event)
+	if err != nil {
+		return nil, err
+	}
+	if executionKey, ok := getExecutionKey(event); ok {
+		if link, ok := correlationLinks.take(executionKey); ok {
+			tags = append(tags, KeyValue{Key: "propertynames", Value: "start_event_link"})
+			tags = append(tags, KeyValue{Key: "propertyvalues", Value: link})
+		}
+	}
+	return tags, nil
 }
 
 func onExperimentStep(event event_kit_api.EventRequestBody) ([]KeyValue, error) {
 	tags := getEventBaseTags(event)
+	tags = append(tags, getCorrelationTags(event)...)
 	tags = append(tags, getExecutionTags(event)...)
 	tags = append(tags, getStepTags(*event.ExperimentStepExecution)...)
 
@@ -125,15 +153,83 @@ func getEventBaseTags(event event_kit_api.EventRequestBody) []KeyValue {
 	return tags
 }
 
-func getExecutionTags(event event_kit_api.EventRequestBody) []KeyValue {
+// eventExecution identifies the experiment execution, step and target an event refers to.
+type eventExecution struct {
+	experimentKey string
+	executionId   float32
+	stepId        string
+	targetId      string
+}
+
+// getEventExecution extracts the execution from whichever part of the event carries it, the most specific one first.
+// It returns false for events without an execution.
+func getEventExecution(event event_kit_api.EventRequestBody) (eventExecution, bool) {
+	switch {
+	case event.ExperimentStepTargetExecution != nil:
+		target := event.ExperimentStepTargetExecution
+		return eventExecution{
+			experimentKey: target.ExperimentKey,
+			executionId:   target.ExecutionId,
+			stepId:        target.StepExecutionId.String(),
+			targetId:      target.Id.String(),
+		}, true
+	case event.ExperimentStepExecution != nil:
+		step := event.ExperimentStepExecution
+		return eventExecution{experimentKey: step.ExperimentKey, executionId: step.ExecutionId, stepId: step.Id.String()}, true
+	case event.ExperimentExecution != nil:
+		execution := event.ExperimentExecution
+		return eventExecution{experimentKey: execution.ExperimentKey, executionId: execution.ExecutionId}, true
+	}
+	return eventExecution{}, false
+}
+
+// key returns the stable key of the experiment execution, e.g. "ADM-1#42".
+func (e eventExecution) key() string {
+	return fmt.Sprintf("%s#%.0f", e.experimentKey, e.executionId)
+}
+
+// getExecutionKey returns the stable key of the experiment execution an event belongs to.
+func getExecutionKey(event event_kit_api.EventRequestBody) (string, bool) {
+	execution, ok := getEventExecution(event)
+	return execution.key(), ok
+}
+
+// getAuditInitiator identifies the team and experiment execution that caused the event to be posted.
+func getAuditInitiator(event event_kit_api.EventRequestBody) appdclient.AuditInitiator {
+	var initiator appdclient.AuditInitiator
+	if event.Team != nil {
+		initiator.Team = event.Team.Key
+	}
+	if execution, ok := getEventExecution(event); ok {
+		initiator.ExperimentKey = execution.experimentKey
+		initiator.ExecutionId = int(execution.executionId)
+		initiator.StepId = execution.stepId
+	}
+	return initiator
+}
+
+// getCorrelationTags returns the properties shared by all events of an experiment execution, so that the whole
+// timeline of an execution can be queried with the same property names.
+func getCorrelationTags(event event_kit_api.EventRequestBody) []KeyValue {
 	tags := make([]KeyValue, 0)
-	if event.ExperimentExecution == nil {
+	execution, ok := getEventExecution(event)
+	if !ok {
 		return tags
 	}
 	tags = append(tags, KeyValue{Key: "propertynames", Value: "exec_id"})
-	tags = append(tags, KeyValue{Key: "propertyvalues", Value: fmt.Sprintf("%g", event.ExperimentExecution.ExecutionId)})
+	tags = append(tags, KeyValue{Key: "propertyvalues", Value: fmt.Sprintf("%.0f", execution.executionId)})
 	tags = append(tags, KeyValue{Key: "propertynames", Value: "exp_key"})
-	tags = append(tags, KeyValue{Key: "propertyvalues", Value: event.ExperimentExecution.ExperimentKey})
+	tags = append(tags, KeyValue{Key: "propertyvalues", Value: execution.experimentKey})
+	tags = append(tags, KeyValue{Key: "propertynames", Value: "exec_key"})
+	tags = append(tags, KeyValue{Key: "propertyvalues", Value: execution.key()})
+	return tags
+}
+
+func getExecutionTags(event event_kit_api.EventRequestBody) []KeyValue {
+	tags := make([]KeyValue, 0)
+	if event.ExperimentExecution == nil {
+		return tags
+	}
 	tags = append(tags, KeyValue{Key: "propertynames", Value: "exp_name"})
 	tags = append(tags, KeyValue{Key: "propertyvalues", Value: event.ExperimentExecution.Name})
 
@@ -148,6 +244,10 @@ func getExecutionTags(event event_kit_api.EventRequestBody) []KeyValue {
 	if event.ExperimentExecution.EndedTime != nil && !(*event.ExperimentExecution.EndedTime).IsZero() {
 		tags = append(tags, KeyValue{Key: "propertynames", Value: "ended_time"})
 		tags = append(tags, KeyValue{Key: "propertyvalues", Value: event.ExperimentExecution.EndedTime.Format(time.RFC3339)})
+		if !event.ExperimentExecution.StartedTime.IsZero() {
+			tags = append(tags, KeyValue{Key: "propertynames", Value: "duration_ms"})
+			tags = append(tags, KeyValue{Key: "propertyvalues", Value: strconv.FormatInt(event.ExperimentExecution.EndedTime.Sub(event.ExperimentExecution.StartedTime).Milliseconds(), 10)})
+		}
 	}
 
 	return tags
@@ -168,10 +268,6 @@ func getStepTags(step event_kit_api.ExperimentStepExecution) []KeyValue {
 		tags = append(tags, KeyValue{Key: "propertynames", Value: "step_label"})
 		tags = append(tags, KeyValue{Key: "propertyvalues", Value: *step.CustomLabel})
 	}
-	tags = append(tags, KeyValue{Key: "propertynames", Value: "exec_id"})
-	tags = append(tags, KeyValue{Key: "propertyvalues", Value: fmt.Sprintf("%.0f", step.ExecutionId)})
-	tags = append(tags, KeyValue{Key: "propertynames", Value: "exp_key"})
-	tags = append(tags, KeyValue{Key: "propertyvalues", Value: step.ExperimentKey})
 	tags = append(tags, KeyValue{Key: "propertynames", Value: "step_id"})
 	tags = append(tags, KeyValue{Key: "propertyvalues", Value: step.Id.String()})
 
@@ -181,94 +277,66 @@ func getStepTags(step event_kit_api.ExperimentStepExecution) []KeyValue {
 func getTargetTags(target event_kit_api.ExperimentStepTargetExecution) []KeyValue {
 	tags := make([]KeyValue, 0)
 
-	tags = append(tags, KeyValue{Key: "propertynames", Value: "execution_id"})
-	tags = append(tags, KeyValue{Key: "propertyvalues", Value: fmt.Sprintf("%.0f", target.ExecutionId)})
-	tags = append(tags, KeyValue{Key: "propertynames", Value: "execution_key"})
-	tags = append(tags, KeyValue{Key: "propertyvalues", Value: target.ExperimentKey})
-	tags = append(tags, KeyValue{Key: "propertynames", Value: "execution_state"})
+	tags = append(tags, KeyValue{Key: "propertynames", Value: "target_state"})
 	tags = append(tags, KeyValue{Key: "propertyvalues", Value: string(target.State)})
 
 	if target.StartedTime != nil {
-		tags = append(tags, KeyValue{Key: "propertynames", Value: "started_time"})
+		tags = append(tags, KeyValue{Key: "propertynames", Value: "target_started_time"})
 		tags = append(tags, KeyValue{Key: "propertyvalues", Value: target.StartedTime.Format(time.RFC3339)})
 	}
 
 	if target.EndedTime != nil {
-		tags = append(tags, KeyValue{Key: "propertynames", Value: "ended_time"})
+		tags = append(tags, KeyValue{Key: "propertynames", Value: "target_ended_time"})
 		tags = append(tags, KeyValue{Key: "propertyvalues", Value: target.EndedTime.Format(time.RFC3339)})
 	}
 
 	return tags
 }
 
-func getTargetProperties(target event_kit_api.ExperimentStepTargetExecution) []KeyValue {
-	tags := make([]KeyValue, 0)
-	const clusterNameSteadybitAttribute = "k8s.cluster-name"
-
-	if _, ok := target.TargetAttributes[clusterNameSteadybitAttribute]; ok {
-		tags = getTargetAttributeToKeyValue(tags, target, clusterNameSteadybitAttribute)
-		tags = getTargetAttributeToKeyValue(tags, target, "k8s.namespace")
-		tags = getTargetAttributeToKeyValue(tags, target, "k8s.deployment")
-		tags = getTargetAttributeToKeyValue(tags, target, "k8s.pod.name")
-		tags = getTargetAttributeToKeyValue(tags, target, "k8s.container.name")
-	}
-
-	tags = getTargetAttributeToKeyValue(tags, target, "container.host")
-	tags = getTargetAttributeToKeyValue(tags, target, "container.host")
-	tags = getTargetAttributeToKeyValue(tags, target, "host.hostname")
-	tags = getTargetAttributeToKeyValue(tags, target, "application.hostname")
+func parseBodyToEventRequestBody(body []byte) (event_kit_api.EventRequestBody, error) {
+	var event event_kit_api.EventRequestBody
+	err := json.Unmarshal(body, &event)
+	return event, err
+}
 
-	tags = getTargetAttributeToKeyValue(tags, target, "container.id.stripped")
+// customEventSink posts events as custom events to the REST API of the AppDynamics controller.
+type customEventSink struct {
+	client CustomEventClient
+}
 
-	if _, ok := target.TargetAttributes["aws.region"]; ok {
-		//AWS tags
-		tags = append(tags, KeyValue{Key: "propertynames", Value: "cloud.provider"})
-		tags = append(tags, KeyValue{Key: "propertyvalues", Value: "aws"})
-		tags = getTargetAttributeToKeyValue(tags, target, "aws.region")
-		tags = getTargetAttributeToKeyValue(tags, target, "aws.zone")
-		tags = getTargetAttributeToKeyValue(tags, target, "aws.account")
-	}
+func NewCustomEventSink(client CustomEventClient) EventSink {
+	return &customEventSink{client: client}
+}
 
-	return tags
+func (s *customEventSink) Name() string {
+	return CustomEventSinkName
 }
 
-func getTargetAttributeToKeyValue(tags []KeyValue, target event_kit_api.ExperimentStepTargetExecution, steadybitAttribute string) []KeyValue {
-	if values, ok := target.TargetAttributes[steadybitAttribute]; ok {
-		if (len(values)) == 1 {
-			tags = append(tags, KeyValue{Key: "propertynames", Value: steadybitAttribute})
-			tags = append(tags, KeyValue{Key: "propertyvalues", Value: values[0]})
+func (s *customEventSink) Post(ctx context.Context, event event_kit_api.EventRequestBody, properties []KeyValue) error {
+	eventId, err := handlePostEvent(appdclient.WithAuditInitiator(ctx, getAuditInitiator(event)), s.client, properties)
+	if err != nil {
+		return err
+	}
+	if config.Config.EventCorrelationLink && event.EventName == "experiment.execution.created" && eventId != "" {
+		if executionKey, ok := getExecutionKey(event); ok {
+			correlationLinks.store(executionKey, getEventLink(s.client.BaseUrl(), eventId), time.Now())
 		}
 	}
-	return tags
+	return nil
 }
 
-func parseBodyToEventRequestBody(body []byte) (event_kit_api.EventRequestBody, error) {
-	var event event_kit_api.EventRequestBody
-	err := json.Unmarshal(body, &event)
-	return event, err
+// getEventLink returns the deep link to a custom event in the AppDynamics controller UI.
+func getEventLink(controllerUrl string, eventId string) string {
+	return strings.TrimSuffix(controllerUrl, "/") + "/controller/#/location=APP_EVENT_VIEWER_MODAL&eventSummary=" + eventId + "&application=" + config.Config.EventApplicationID
 }
 
-func handlePostEvent(ctx context.Context, client *resty.Client, queryParameters []KeyValue) {
+// handlePostEvent posts a custom event and returns the id of the created event if the controller reported it.
+func handlePostEvent(ctx context.Context, client CustomEventClient, queryParameters []KeyValue) (string, error) {
 	query, err := buildOrderedQueryString(queryParameters)
 	if err != nil {
-		log.Err(err).Msgf("Failed to create query string for the custom event: %v", err)
-		return
-	}
-	req := client.R().
-		SetContext(ctx).
-		SetQueryString(query)
-
-	res, err := req.
-		Post("/controller/rest/applications/" + config.Config.EventApplicationID + "/events")
-
-	if err != nil {
-		log.Err(err).Msgf("Failed to post custom event. Full response: %v", res.String())
-		return
-	}
-
-	if !res.IsSuccess() {
-		log.Err(err).Msgf("AppDynamics API responded with unexpected status code %d while posting events. Full response: %v", res.StatusCode(), res.String())
+		return "", fmt.Errorf("failed to create query string for the custom event: %w", err)
 	}
+	return client.PostEvent(ctx, config.Config.EventApplicationID, query)
 }
 
 func onExperimentTarget(event event_kit_api.EventRequestBody) ([]KeyValue, error) {
@@ -285,6 +353,7 @@ func onExperimentTarget(event event_kit_api.EventRequestBody) ([]KeyValue, error
 
 	if stepExecution.ActionKind != nil && *stepExecution.ActionKind == event_kit_api.Attack {
 		tags := getEventBaseTags(event)
+		tags = append(tags, getCorrelationTags(event)...)
 		tags = append(tags, getExecutionTags(event)...)
 		tags = append(tags, getTargetTags(*event.ExperimentStepTargetExecution)...)
 		tags = append(tags, getTargetProperties(*event.ExperimentStepTargetExecution)...)
@@ -17,8 +17,10 @@ import (
 	"github.com/go-resty/resty/v2"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/steadybit/event-kit/go/event_kit_api"
+	"github.com/steadybit/extension-appdynamics/appdclient"
 	"github.com/steadybit/extension-appdynamics/config"
 )
 
@@ -151,9 +153,36 @@ func TestGetExecutionTags_FullExecution(t *testing.T) {
 	}
 	tags := getExecutionTags(ev)
 
-	assert.Contains(t, tags, KeyValue{Key: "propertynames", Value: "exec_id"})
-	assert.Contains(t, tags, KeyValue{Key: "propertynames", Value: "exp_key"})
 	assert.Contains(t, tags, KeyValue{Key: "propertynames", Value: "exp_name"})
+	assert.Contains(t, tags, KeyValue{Key: "propertynames", Value: "duration_ms"})
+	assert.Contains(t, tags, KeyValue{Key: "propertyvalues", Value: "7200000"})
+}
+
+// --- getCorrelationTags ---
+
+func TestGetCorrelationTags_SameForAllEventsOfAnExecution(t *testing.T) {
+	execution := event_kit_api.EventRequestBody{
+		ExperimentExecution: &event_kit_api.ExperimentExecution{ExecutionId: 1234567, ExperimentKey: "ADM-1"},
+	}
+	step := event_kit_api.EventRequestBody{
+		ExperimentStepExecution: &event_kit_api.ExperimentStepExecution{ExecutionId: 1234567, ExperimentKey: "ADM-1"},
+	}
+	target := event_kit_api.EventRequestBody{
+		ExperimentStepTargetExecution: &event_kit_api.ExperimentStepTargetExecution{ExecutionId: 1234567, ExperimentKey: "ADM-1"},
+	}
+
+	expected := []KeyValue{
+		{Key: "propertynames", Value: "exec_id"},
+		{Key: "propertyvalues", Value: "1234567"},
+		{Key: "propertynames", Value: "exp_key"},
+		{Key: "propertyvalues", Value: "ADM-1"},
+		{Key: "propertynames", Value: "exec_key"},
+		{Key: "propertyvalues", Value: "ADM-1#1234567"},
+	}
+	assert.Equal(t, expected, getCorrelationTags(execution))
+	assert.Equal(t, expected, getCorrelationTags(step))
+	assert.Equal(t, expected, getCorrelationTags(target))
+	assert.Empty(t, getCorrelationTags(event_kit_api.EventRequestBody{}))
 }
 
 // --- getStepTags ---
@@ -188,8 +217,9 @@ func TestGetTargetTags_WithTimes(t *testing.T) {
 		EndedTime:     &end,
 	}
 	tags := getTargetTags(tgt)
-	assert.Contains(t, tags, KeyValue{Key: "propertynames", Value: "execution_id"})
-	assert.Contains(t, tags, KeyValue{Key: "propertynames", Value: "execution_key"})
+	assert.Contains(t, tags, KeyValue{Key: "propertynames", Value: "target_state"})
+	assert.Contains(t, tags, KeyValue{Key: "propertynames", Value: "target_started_time"})
+	assert.Contains(t, tags, KeyValue{Key: "propertynames", Value: "target_ended_time"})
 }
 
 // --- getTargetProperties ---
@@ -326,7 +356,7 @@ func TestHandlePostEvent_Success(t *testing.T) {
 	called := false
 	var capturedURL *url.URL
 
-	RestyClient = newResty(func(req *http.Request) (*http.Response, error) {
+	client := newResty(func(req *http.Request) (*http.Response, error) {
 		called = true
 		capturedURL = req.URL
 		// return OK
@@ -339,7 +369,8 @@ func TestHandlePostEvent_Success(t *testing.T) {
 
 	// simple kv
 	kv := []KeyValue{{Key: "customeventtype", Value: "Steadybit"}}
-	handlePostEvent(context.Background(), RestyClient, kv)
+	_, err := handlePostEvent(context.Background(), appdclient.New(client), kv)
+	assert.NoError(t, err)
 	assert.True(t, called)
 	assert.Equal(t, "/controller/rest/applications/theApp/events", capturedURL.Path)
 	assert.Equal(t, "customeventtype=Steadybit", capturedURL.RawQuery)
@@ -347,11 +378,46 @@ func TestHandlePostEvent_Success(t *testing.T) {
 
 func TestHandlePostEvent_BuildQueryError(t *testing.T) {
 	// mismatched so buildOrderedQueryString fails
-	RestyClient = newResty(func(req *http.Request) (*http.Response, error) {
+	client := newResty(func(req *http.Request) (*http.Response, error) {
 		t.Fatalf("should not be called when query building fails")
 		return nil, errors.New("boom")
 	})
 	// one propertynames, zero values → error
-	handlePostEvent(context.Background(), RestyClient, []KeyValue{{Key: "propertynames", Value: "n1"}})
-	// no panic, nothing else to assert
+	_, err := handlePostEvent(context.Background(), appdclient.New(client), []KeyValue{{Key: "propertynames", Value: "n1"}})
+	assert.Error(t, err)
+}
+
+func TestCustomEventSink_LinksStartEventInCompletionEvent(t *testing.T) {
+	oldConfig := config.Config
+	t.Cleanup(func() { config.Config = oldConfig })
+	config.Config.EventApplicationID = "theApp"
+	config.Config.EventCorrelationLink = true
+	correlationLinks = newExecutionState[string]()
+
+	client := newResty(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(strings.NewReader("Successfully created the event id: 4711")),
+		}, nil
+	})
+	client.SetBaseURL("https://acme.saas.appdynamics.com/")
+	execution := &event_kit_api.ExperimentExecution{ExecutionId: 42, ExperimentKey: "ADM-1"}
+	started := event_kit_api.EventRequestBody{
+		EventName:           "experiment.execution.created",
+		Environment:         &event_kit_api.Environment{Name: "E"},
+		Team:                &event_kit_api.Team{Name: "test", Key: "test"},
+		ExperimentExecution: execution,
+	}
+	properties, err := onExperiment(started)
+	require.NoError(t, err)
+	require.NoError(t, NewCustomEventSink(appdclient.New(client)).Post(context.Background(), started, properties))
+
+	completed := started
+	completed.EventName = "experiment.execution.completed"
+	tags, err := onExperimentCompleted(completed)
+	require.NoError(t, err)
+
+	assert.Contains(t, tags, KeyValue{Key: "propertynames", Value: "start_event_link"})
+	assert.Contains(t, tags, KeyValue{Key: "propertyvalues", Value: "https://acme.saas.appdynamics.com/controller/#/location=APP_EVENT_VIEWER_MODAL&eventSummary=4711&application=theApp"})
+	assert.Equal(t, 0, correlationLinks.len())
 }
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extevents
+
+import (
+	"sync"
+	"time"
+)
+
+// executionStateTtl is how long per-execution state is kept without being updated. It bounds the state of executions
+// whose completion event is never handled, e.g. because the extension restarted or the platform crashed.
+const executionStateTtl = 24 * time.Hour
+
+// executionState holds a value per execution until the execution completes and takes it, or until it expires.
+type executionState[V any] struct {
+	mutex   sync.Mutex
+	entries map[string]executionStateEntry[V]
+}
+
+type executionStateEntry[V any] struct {
+	value   V
+	updated time.Time
+}
+
+func newExecutionState[V any]() *executionState[V] {
+	return &executionState[V]{entries: map[string]executionStateEntry[V]{}}
+}
+
+// update replaces the value of the execution with the result of the function, which gets the current or zero value.
+func (s *executionState[V]) update(key string, now time.Time, update func(V) V) V {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.evict(now)
+	value := update(s.entries[key].value)
+	s.entries[key] = executionStateEntry[V]{value: value, updated: now}
+	return value
+}
+
+func (s *executionState[V]) store(key string, value V, now time.Time) {
+	s.update(key, now, func(V) V { return value })
+}
+
+// take returns the value of the execution and forgets about it.
+func (s *executionState[V]) take(key string) (V, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.entries[key]
+	delete(s.entries, key)
+	return entry.value, ok
+}
+
+func (s *executionState[V]) evict(now time.Time) {
+	for key, entry := range s.entries {
+		if now.Sub(entry.updated) >= executionStateTtl {
+			delete(s.entries, key)
+		}
+	}
+}
+
+func (s *executionState[V]) len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.entries)
+}
@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extevents
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutionState_EvictsExpiredExecutions(t *testing.T) {
+	state := newExecutionState[int64]()
+	now := time.Now()
+	state.store("abandoned", 3, now)
+	state.store("running", 1, now.Add(executionStateTtl/2))
+
+	assert.Equal(t, int64(2), state.update("running", now.Add(executionStateTtl), func(count int64) int64 { return count + 1 }))
+	assert.Equal(t, 1, state.len())
+	_, ok := state.take("abandoned")
+	assert.False(t, ok)
+
+	count, ok := state.take("running")
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, 0, state.len())
+}
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extevents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/event-kit/go/event_kit_api"
+	"github.com/steadybit/extension-appdynamics/config"
+)
+
+const (
+	CustomEventSinkName    = "customEvent"
+	AnalyticsEventSinkName = "analytics"
+	LogFileEventSinkName   = "logFile"
+	WebhookEventSinkName   = "webhook"
+)
+
+// EventSink receives the experiment lifecycle events the extension listens to. properties are the AppDynamics
+// custom event properties derived from the event, so sinks can either forward them or work on the raw event.
+type EventSink interface {
+	Name() string
+	Post(ctx context.Context, event event_kit_api.EventRequestBody, properties []KeyValue) error
+}
+
+type registeredSink struct {
+	sink EventSink
+	// eventNames are glob patterns of the event names forwarded to the sink. Empty means all events.
+	eventNames []string
+}
+
+var (
+	sinksMutex sync.RWMutex
+	sinks      []registeredSink
+)
+
+var eventPosts = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "steadybit_appdynamics_event_posts_total",
+	Help: "Number of events posted to the event sinks, by sink and result.",
+}, []string{"sink", "result"})
+
+// RegisterEventSink adds a sink receiving all events whose name matches one of the given glob patterns, or every
+// event if no pattern is given.
+func RegisterEventSink(sink EventSink, eventNames ...string) {
+	sinksMutex.Lock()
+	defer sinksMutex.Unlock()
+	sinks = append(sinks, registeredSink{sink: sink, eventNames: eventNames})
+	log.Info().Strs("eventNames", eventNames).Msgf("Registered event sink '%s'.", sink.Name())
+}
+
+func ClearEventSinks() {
+	sinksMutex.Lock()
+	defer sinksMutex.Unlock()
+	sinks = nil
+}
+
+// IsEnabled reports whether at least one event sink is registered.
+func IsEnabled() bool {
+	sinksMutex.RLock()
+	defer sinksMutex.RUnlock()
+	return len(sinks) > 0
+}
+
+// RegisterEventSinks registers the sinks configured through STEADYBIT_EXTENSION_EVENT_SINKS. Without explicit
+// configuration, custom events and analytics events are enabled when their destination is configured.
+func RegisterEventSinks(client CustomEventClient, analyticsClient AnalyticsClient) {
+	names := config.Config.EventSinks
+	if len(names) == 0 {
+		if config.Config.EventApplicationID != "" {
+			names = append(names, CustomEventSinkName)
+		}
+		if config.Config.AnalyticsEventsApiUrl != "" {
+			names = append(names, AnalyticsEventSinkName)
+		}
+	}
+
+	for _, name := range names {
+		var sink EventSink
+		switch name {
+		case CustomEventSinkName:
+			sink = NewCustomEventSink(client)
+		case AnalyticsEventSinkName:
+			sink = NewAnalyticsEventSink(analyticsClient)
+		case LogFileEventSinkName:
+			sink = NewLogFileEventSink(config.Config.EventLogFilePath)
+		case WebhookEventSinkName:
+			sink = NewWebhookEventSink(config.Config.EventWebhookUrl, config.Config.EventWebhookHeaders)
+		default:
+			log.Warn().Msgf("Ignoring unknown event sink '%s'.", name)
+			continue
+		}
+		RegisterEventSink(sink, getEventNameFilter(name)...)
+	}
+}
+
+func getEventNameFilter(sinkName string) []string {
+	filter := config.Config.EventSinkFilters[sinkName]
+	if filter == "" {
+		return nil
+	}
+	return strings.Split(filter, "|")
+}
+
+func (s registeredSink) accepts(eventName string) bool {
+	return len(s.eventNames) == 0 || slices.ContainsFunc(s.eventNames, func(pattern string) bool {
+		matched, err := path.Match(pattern, eventName)
+		return err == nil && matched
+	})
+}
+
+// dispatch forwards an event to all accepting sinks in parallel, so a slow sink doesn't delay the others. It returns an
+// error if the event was accepted by sinks, but none of them posted it, so the platform can send it again.
+func dispatch(ctx context.Context, event event_kit_api.EventRequestBody, properties []KeyValue) error {
+	sinksMutex.RLock()
+	defer sinksMutex.RUnlock()
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var errs []error
+	accepted := 0
+	for _, s := range sinks {
+		if !s.accepts(event.EventName) {
+			continue
+		}
+		accepted++
+		wg.Go(func() {
+			if err := s.sink.Post(ctx, event, properties); err != nil {
+				eventPosts.WithLabelValues(s.sink.Name(), "failure").Inc()
+				log.Err(err).Msgf("Event sink '%s' failed to post event '%s'.", s.sink.Name(), event.EventName)
+				mutex.Lock()
+				errs = append(errs, fmt.Errorf("event sink '%s': %w", s.sink.Name(), err))
+				mutex.Unlock()
+				return
+			}
+			eventPosts.WithLabelValues(s.sink.Name(), "success").Inc()
+		})
+	}
+	wg.Wait()
+
+	if accepted > 0 && len(errs) == accepted {
+		return fmt.Errorf("failed to post event '%s': %w", event.EventName, errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extevents
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/steadybit/event-kit/go/event_kit_api"
+)
+
+// logFileEventSink appends every event as a JSON line to a file.
+type logFileEventSink struct {
+	path  string
+	mutex sync.Mutex
+}
+
+type eventRecord struct {
+	Event      event_kit_api.EventRequestBody `json:"event"`
+	Properties map[string]string              `json:"properties"`
+}
+
+func NewLogFileEventSink(path string) EventSink {
+	return &logFileEventSink{path: path}
+}
+
+func (s *logFileEventSink) Name() string {
+	return LogFileEventSinkName
+}
+
+func (s *logFileEventSink) Post(_ context.Context, event event_kit_api.EventRequestBody, properties []KeyValue) error {
+	line, err := json.Marshal(toEventRecord(event, properties))
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// toEventRecord pairs the custom event property names and values, so the record can be processed without knowing
+// about the query string format of the AppDynamics REST API.
+func toEventRecord(event event_kit_api.EventRequestBody, properties []KeyValue) eventRecord {
+	record := eventRecord{Event: event, Properties: make(map[string]string)}
+	var names, values []string
+	for _, kv := range properties {
+		switch kv.Key {
+		case "propertynames":
+			names = append(names, kv.Value)
+		case "propertyvalues":
+			values = append(values, kv.Value)
+		default:
+			record.Properties[kv.Key] = kv.Value
+		}
+	}
+	for i := 0; i < len(names) && i < len(values); i++ {
+		record.Properties[names[i]] = values[i]
+	}
+	return record
+}
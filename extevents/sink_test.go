@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extevents
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/steadybit/event-kit/go/event_kit_api"
+	"github.com/steadybit/extension-appdynamics/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	name   string
+	err    error
+	mutex  sync.Mutex
+	events []string
+}
+
+func (s *recordingSink) Name() string {
+	return s.name
+}
+
+func (s *recordingSink) Post(_ context.Context, event event_kit_api.EventRequestBody, _ []KeyValue) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.events = append(s.events, event.EventName)
+	return s.err
+}
+
+func TestDispatch_AppliesEventNameFilters(t *testing.T) {
+	ClearEventSinks()
+	t.Cleanup(ClearEventSinks)
+	all := &recordingSink{name: "all"}
+	completedOnly := &recordingSink{name: "completed", err: errors.New("boom")}
+	RegisterEventSink(all)
+	RegisterEventSink(completedOnly, "experiment.execution.completed", "experiment.execution.failed")
+
+	allPostsBefore := testutil.ToFloat64(eventPosts.WithLabelValues("all", "success"))
+	completedFailuresBefore := testutil.ToFloat64(eventPosts.WithLabelValues("completed", "failure"))
+
+	assert.NoError(t, dispatch(context.Background(), event_kit_api.EventRequestBody{EventName: "experiment.execution.created"}, nil))
+	assert.NoError(t, dispatch(context.Background(), event_kit_api.EventRequestBody{EventName: "experiment.execution.failed"}, nil))
+
+	assert.Equal(t, []string{"experiment.execution.created", "experiment.execution.failed"}, all.events)
+	assert.Equal(t, []string{"experiment.execution.failed"}, completedOnly.events)
+	assert.Equal(t, allPostsBefore+2, testutil.ToFloat64(eventPosts.WithLabelValues("all", "success")))
+	assert.Equal(t, completedFailuresBefore+1, testutil.ToFloat64(eventPosts.WithLabelValues("completed", "failure")))
+}
+
+func TestRegisterEventSinks_DefaultsToConfiguredDestinations(t *testing.T) {
+	ClearEventSinks()
+	t.Cleanup(ClearEventSinks)
+	old := config.Config
+	t.Cleanup(func() { config.Config = old })
+
+	config.Config.EventSinks = nil
+	config.Config.EventApplicationID = ""
+	config.Config.AnalyticsEventsApiUrl = ""
+	RegisterEventSinks(nil, nil)
+	assert.False(t, IsEnabled())
+
+	config.Config.EventApplicationID = "42"
+	config.Config.EventSinkFilters = map[string]string{CustomEventSinkName: "experiment.execution.*"}
+	RegisterEventSinks(nil, nil)
+	require.Len(t, sinks, 1)
+	assert.Equal(t, CustomEventSinkName, sinks[0].sink.Name())
+	assert.True(t, sinks[0].accepts("experiment.execution.step-started"))
+	assert.False(t, sinks[0].accepts("experiment.other"))
+}
+
+func TestLogFileEventSink_AppendsJsonLines(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "events.log")
+	sink := NewLogFileEventSink(file)
+	properties := []KeyValue{
+		{Key: "summary", Value: "Steadybit"},
+		{Key: "propertynames", Value: "exec_id"},
+		{Key: "propertyvalues", Value: "42"},
+	}
+
+	require.NoError(t, sink.Post(context.Background(), event_kit_api.EventRequestBody{EventName: "first"}, properties))
+	require.NoError(t, sink.Post(context.Background(), event_kit_api.EventRequestBody{EventName: "second"}, nil))
+
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+	var records []eventRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record eventRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 2)
+	assert.Equal(t, "first", records[0].Event.EventName)
+	assert.Equal(t, map[string]string{"summary": "Steadybit", "exec_id": "42"}, records[0].Properties)
+	assert.Equal(t, "second", records[1].Event.EventName)
+}
+
+func TestWebhookEventSink_PostsJson(t *testing.T) {
+	var received eventRecord
+	var token string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	sink := NewWebhookEventSink(ts.URL+"/hook", map[string]string{"Authorization": "Bearer abc"})
+	err := sink.Post(context.Background(), event_kit_api.EventRequestBody{EventName: "experiment.execution.completed"}, []KeyValue{{Key: "propertynames", Value: "Team"}, {Key: "propertyvalues", Value: "ADM"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer abc", token)
+	assert.Equal(t, "experiment.execution.completed", received.Event.EventName)
+	assert.Equal(t, "ADM", received.Properties["Team"])
+}
+
+func TestWebhookEventSink_ReturnsErrorOnUnexpectedStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	err := NewWebhookEventSink(ts.URL, nil).Post(context.Background(), event_kit_api.EventRequestBody{}, nil)
+	assert.Error(t, err)
+}
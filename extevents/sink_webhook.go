@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extevents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/steadybit/event-kit/go/event_kit_api"
+)
+
+// webhookEventSink posts every event as JSON to a generic webhook.
+type webhookEventSink struct {
+	url    string
+	client *resty.Client
+}
+
+func NewWebhookEventSink(url string, headers map[string]string) EventSink {
+	return &webhookEventSink{
+		url:    url,
+		client: resty.New().SetHeaders(headers).SetHeader("Content-Type", "application/json"),
+	}
+}
+
+func (s *webhookEventSink) Name() string {
+	return WebhookEventSinkName
+}
+
+func (s *webhookEventSink) Post(ctx context.Context, event event_kit_api.EventRequestBody, properties []KeyValue) error {
+	res, err := s.client.R().
+		SetContext(ctx).
+		SetBody(toEventRecord(event, properties)).
+		Post(s.url)
+
+	if err != nil {
+		return fmt.Errorf("failed to post event to webhook: %w", err)
+	}
+
+	if !res.IsSuccess() {
+		return fmt.Errorf("webhook responded with unexpected status code %d. Full response: %v", res.StatusCode(), res.String())
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extevents
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/event-kit/go/event_kit_api"
+	"github.com/steadybit/extension-appdynamics/config"
+)
+
+// defaultTargetAttributes are attached to target events unless excluded through the configuration.
+// Additional attributes can be added with STEADYBIT_EXTENSION_EVENT_TARGET_ATTRIBUTE_INCLUDES.
+var defaultTargetAttributes = []string{
+	clusterNameAttribute,
+	"k8s.namespace",
+	"k8s.deployment",
+	"k8s.pod.name",
+	"k8s.container.name",
+	"container.host",
+	"host.hostname",
+	"application.hostname",
+	"container.id.stripped",
+	"aws.region",
+	"aws.zone",
+	"aws.account",
+	"gcp.project.id",
+	"gcp.region",
+	"gcp.zone",
+	"azure.subscription.id",
+	"azure.resource-group.name",
+	"azure.location",
+}
+
+// clusterNameAttribute has to be present for the default k8s.* attributes to be attached, as targets outside of
+// Kubernetes may carry partial k8s.* attributes.
+const clusterNameAttribute = "k8s.cluster-name"
+
+// cloudProviders derives the cloud.provider property from the attribute prefix of the mapped attributes.
+var cloudProviders = []struct {
+	prefix   string
+	provider string
+}{
+	{prefix: "aws.", provider: "aws"},
+	{prefix: "gcp.", provider: "gcp"},
+	{prefix: "azure.", provider: "azure"},
+}
+
+// getTargetProperties returns the target properties of the event, the derived cloud.provider first. The cap of
+// config.Specification.EventTargetAttributeMaxProperties applies to all of them, including cloud.provider.
+func getTargetProperties(target event_kit_api.ExperimentStepTargetExecution) []KeyValue {
+	properties := make([]KeyValue, 0)
+	attributes := getMappedTargetAttributes(target.TargetAttributes)
+
+	for _, cloud := range cloudProviders {
+		if slices.ContainsFunc(attributes, func(attribute string) bool { return strings.HasPrefix(attribute, cloud.prefix) }) {
+			properties = append(properties, KeyValue{Key: "cloud.provider", Value: cloud.provider})
+			break
+		}
+	}
+
+	for _, attribute := range attributes {
+		propertyName := attribute
+		if renamed, ok := config.Config.EventTargetAttributeRenames[attribute]; ok && renamed != "" {
+			propertyName = renamed
+		}
+		properties = append(properties, KeyValue{Key: propertyName, Value: target.TargetAttributes[attribute][0]})
+	}
+
+	maxProperties := config.Config.EventTargetAttributeMaxProperties
+	if maxProperties > 0 && len(properties) > maxProperties {
+		for _, skipped := range properties[maxProperties:] {
+			log.Debug().Msgf("Skipping target property '%s', the event already carries the maximum of %d target properties.", skipped.Key, maxProperties)
+		}
+		properties = properties[:maxProperties]
+	}
+
+	tags := make([]KeyValue, 0, 2*len(properties))
+	for _, property := range properties {
+		tags = append(tags, KeyValue{Key: "propertynames", Value: property.Key})
+		tags = append(tags, KeyValue{Key: "propertyvalues", Value: property.Value})
+	}
+	return tags
+}
+
+// getMappedTargetAttributes returns the names of the single-valued target attributes that should be attached to
+// the event, in a stable order: the built-in attributes first, followed by the configured includes. Glob patterns
+// are expanded in alphabetical order. The default k8s.* attributes are only attached along with the cluster name.
+func getMappedTargetAttributes(targetAttributes map[string][]string) []string {
+	keys := make([]string, 0, len(targetAttributes))
+	for key, values := range targetAttributes {
+		if len(values) == 1 {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	_, hasClusterName := targetAttributes[clusterNameAttribute]
+	result := make([]string, 0)
+	for i, pattern := range slices.Concat(defaultTargetAttributes, config.Config.EventTargetAttributeIncludes) {
+		isDefault := i < len(defaultTargetAttributes)
+		for _, key := range keys {
+			if slices.Contains(result, key) || !matchesPattern(pattern, key) || matchesAnyPattern(config.Config.EventTargetAttributeExcludes, key) {
+				continue
+			}
+			if isDefault && !hasClusterName && strings.HasPrefix(key, "k8s.") {
+				continue
+			}
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+func matchesAnyPattern(patterns []string, name string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool { return matchesPattern(pattern, name) })
+}
+
+func matchesPattern(pattern string, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2025 Steadybit GmbH
+
+package extevents
+
+import (
+	"testing"
+
+	"github.com/steadybit/event-kit/go/event_kit_api"
+	"github.com/steadybit/extension-appdynamics/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func withTargetAttributeConfig(t *testing.T, includes, excludes []string, renames map[string]string, maxProperties int) {
+	old := config.Config
+	config.Config.EventTargetAttributeIncludes = includes
+	config.Config.EventTargetAttributeExcludes = excludes
+	config.Config.EventTargetAttributeRenames = renames
+	config.Config.EventTargetAttributeMaxProperties = maxProperties
+	t.Cleanup(func() { config.Config = old })
+}
+
+func propertyNames(tags []KeyValue) []string {
+	names := make([]string, 0)
+	for _, kv := range tags {
+		if kv.Key == "propertynames" {
+			names = append(names, kv.Value)
+		}
+	}
+	return names
+}
+
+func TestGetTargetProperties_DefaultsAddContainerHostOnce(t *testing.T) {
+	withTargetAttributeConfig(t, nil, nil, nil, 0)
+	tags := getTargetProperties(event_kit_api.ExperimentStepTargetExecution{
+		TargetAttributes: map[string][]string{
+			"container.host": {"host1"},
+			"team.owner":     {"checkout"},
+		},
+	})
+
+	assert.Equal(t, []string{"container.host"}, propertyNames(tags))
+}
+
+func TestGetTargetProperties_IncludesExcludesAndRenames(t *testing.T) {
+	withTargetAttributeConfig(t, []string{"team.owner", "service.*"}, []string{"k8s.*"}, map[string]string{"team.owner": "owner"}, 0)
+	tags := getTargetProperties(event_kit_api.ExperimentStepTargetExecution{
+		TargetAttributes: map[string][]string{
+			"k8s.cluster-name": {"cluster"},
+			"k8s.namespace":    {"ns"},
+			"host.hostname":    {"host"},
+			"team.owner":       {"checkout"},
+			"service.tier":     {"gold"},
+			"service.labels":   {"a", "b"},
+		},
+	})
+
+	assert.Equal(t, []string{"host.hostname", "owner", "service.tier"}, propertyNames(tags))
+	assert.Contains(t, tags, KeyValue{Key: "propertyvalues", Value: "checkout"})
+}
+
+func TestGetTargetProperties_CloudProviders(t *testing.T) {
+	withTargetAttributeConfig(t, nil, nil, nil, 0)
+	for provider, attributes := range map[string]map[string][]string{
+		"aws":   {"aws.region": {"eu-central-1"}},
+		"gcp":   {"gcp.zone": {"europe-west3-a"}},
+		"azure": {"azure.location": {"westeurope"}},
+	} {
+		tags := getTargetProperties(event_kit_api.ExperimentStepTargetExecution{TargetAttributes: attributes})
+		assert.Equal(t, KeyValue{Key: "propertynames", Value: "cloud.provider"}, tags[0])
+		assert.Equal(t, KeyValue{Key: "propertyvalues", Value: provider}, tags[1])
+	}
+}
+
+func TestGetTargetProperties_MaxProperties(t *testing.T) {
+	withTargetAttributeConfig(t, []string{"custom.*"}, nil, nil, 2)
+	tags := getTargetProperties(event_kit_api.ExperimentStepTargetExecution{
+		TargetAttributes: map[string][]string{
+			"host.hostname": {"host"},
+			"custom.a":      {"a"},
+			"custom.b":      {"b"},
+		},
+	})
+
+	assert.Equal(t, []string{"host.hostname", "custom.a"}, propertyNames(tags))
+}
+
+func TestGetTargetProperties_MaxPropertiesIncludesCloudProvider(t *testing.T) {
+	withTargetAttributeConfig(t, nil, nil, nil, 2)
+	tags := getTargetProperties(event_kit_api.ExperimentStepTargetExecution{
+		TargetAttributes: map[string][]string{
+			"host.hostname": {"host"},
+			"aws.region":    {"eu-central-1"},
+			"aws.zone":      {"eu-central-1a"},
+		},
+	})
+
+	assert.Equal(t, []string{"cloud.provider", "host.hostname"}, propertyNames(tags))
+}
+
+func TestGetTargetProperties_DefaultKubernetesAttributesRequireClusterName(t *testing.T) {
+	withTargetAttributeConfig(t, []string{"k8s.node.name"}, nil, nil, 0)
+	attributes := map[string][]string{
+		"k8s.namespace": {"ns"},
+		"k8s.node.name": {"node"},
+		"host.hostname": {"host"},
+	}
+
+	tags := getTargetProperties(event_kit_api.ExperimentStepTargetExecution{TargetAttributes: attributes})
+	assert.Equal(t, []string{"host.hostname", "k8s.node.name"}, propertyNames(tags))
+
+	attributes["k8s.cluster-name"] = []string{"cluster"}
+	tags = getTargetProperties(event_kit_api.ExperimentStepTargetExecution{TargetAttributes: attributes})
+	assert.Equal(t, []string{"k8s.cluster-name", "k8s.namespace", "host.hostname", "k8s.node.name"}, propertyNames(tags))
+}
@@ -5,21 +5,21 @@
 package main
 
 import (
-	"context"
-	"encoding/base64"
-	"fmt"
 	"net/http"
 	_ "net/http/pprof" //allow pprof
 	"strings"
 
 	_ "github.com/KimMachineGun/automemlimit" // By default, it sets `GOMEMLIMIT` to 90% of cgroup's memory limit.
 	"github.com/go-resty/resty/v2"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"github.com/steadybit/action-kit/go/action_kit_api/v2"
 	"github.com/steadybit/action-kit/go/action_kit_sdk"
 	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
 	"github.com/steadybit/discovery-kit/go/discovery_kit_sdk"
 	"github.com/steadybit/event-kit/go/event_kit_api"
+	"github.com/steadybit/extension-appdynamics/appdclient"
 	"github.com/steadybit/extension-appdynamics/config"
 	"github.com/steadybit/extension-appdynamics/extappdynamics"
 	"github.com/steadybit/extension-appdynamics/extevents"
@@ -29,8 +29,6 @@ import (
 	"github.com/steadybit/extension-kit/extlogging"
 	"github.com/steadybit/extension-kit/extruntime"
 	"github.com/steadybit/extension-kit/extsignals"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/clientcredentials"
 )
 
 func main() {
@@ -44,85 +42,186 @@ func main() {
 
 	config.ParseConfiguration()
 	config.ValidateConfiguration()
-	initRestyClient()
+	initTracing()
+	transport := initTransport()
+	auditLog := initAuditLog()
+	controllers, defaultClient, reloadSecrets := initControllers(transport, auditLog)
+	extevents.RegisterEventSinks(defaultClient, initAnalyticsClient(transport, auditLog))
 
-	discovery_kit_sdk.Register(extappdynamics.NewApplicationDiscovery())
-	discovery_kit_sdk.Register(extappdynamics.NewHealthRuleDiscovery())
-	action_kit_sdk.RegisterAction(extappdynamics.NewHealthRuleStateCheckAction())
-	action_kit_sdk.RegisterAction(extappdynamics.NewActionSuppressionAction())
+	if config.IsDiscoveryEnabled(config.DiscoveryApplication) {
+		discovery_kit_sdk.Register(extappdynamics.NewApplicationDiscovery(controllers))
+	}
+	if config.IsDiscoveryEnabled(config.DiscoveryHealthRule) {
+		discovery_kit_sdk.Register(extappdynamics.NewHealthRuleDiscovery(controllers))
+	}
+	if config.IsDiscoveryEnabled(config.DiscoveryMachine) {
+		discovery_kit_sdk.Register(extappdynamics.NewMachineDiscovery(controllers))
+	}
+	if config.IsDiscoveryEnabled(config.DiscoveryDatabase) {
+		discovery_kit_sdk.Register(extappdynamics.NewDatabaseDiscovery(controllers))
+	}
+	if config.IsDiscoveryEnabled(config.DiscoveryBackend) {
+		discovery_kit_sdk.Register(extappdynamics.NewBackendDiscovery(controllers))
+	}
+	if config.IsActionEnabled(config.ActionHealthRuleCheck) {
+		action_kit_sdk.RegisterAction(extappdynamics.NewHealthRuleStateCheckAction(controllers))
+	}
+	if config.IsActionEnabled(config.ActionActionSuppression) {
+		actionStore, err := extappdynamics.NewActionStore(config.Config.ActionStateDir)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Failed to load the action state from '%s'.", config.Config.ActionStateDir)
+		}
+		extappdynamics.StartDeletingExpiredActionSuppressions(controllers, actionStore)
+		action_kit_sdk.RegisterAction(extappdynamics.NewActionSuppressionAction(controllers, actionStore))
+	}
+	if config.IsActionEnabled(config.ActionPermissionCheck) {
+		action_kit_sdk.RegisterAction(extappdynamics.NewPermissionCheckAction(controllers))
+	}
+	if config.IsActionEnabled(config.ActionMachineCheck) {
+		action_kit_sdk.RegisterAction(extappdynamics.NewMachineCheckAction(controllers))
+	}
+	if config.IsActionEnabled(config.ActionDatabaseCheck) {
+		action_kit_sdk.RegisterAction(extappdynamics.NewDatabaseCheckAction(controllers))
+	}
+	if config.IsActionEnabled(config.ActionBackendCheck) {
+		action_kit_sdk.RegisterAction(extappdynamics.NewBackendCheckAction(controllers))
+	}
 
-	if config.Config.EventApplicationID != "" {
+	if extevents.IsEnabled() {
 		extevents.RegisterEventListenerHandlers()
 	}
 
 	exthttp.RegisterRevisionedHandler("/", getExtensionList)
+	http.Handle("/metrics", promhttp.Handler())
+	connectivityProbe := extappdynamics.NewConnectivityProbe(controllers, config.Config.ConnectivityReadiness)
+	http.Handle("/diagnostics", connectivityProbe)
 
 	extsignals.ActivateSignalHandlers()
+	config.StartReloading(func() {
+		if err := config.ReloadConfigFile(); err != nil {
+			log.Error().Err(err).Msg("Failed to reload the config file.")
+		}
+		reloadSecrets()
+	})
 
 	action_kit_sdk.RegisterCoverageEndpoints()
 
-	exthealth.SetReady(true)
+	connectivityProbe.Start(config.Config.ConnectivityProbeInterval)
 
 	exthttp.Listen(exthttp.ListenOpts{
 		Port: 8083,
 	})
 }
 
-func initRestyClient() {
-	if config.Config.AccessToken != "" {
-		extappdynamics.RestyClient = resty.New()
-		extappdynamics.RestyClient.SetBaseURL(strings.TrimRight(config.Config.ApiBaseUrl, "/"))
-		extappdynamics.RestyClient.SetHeader("Authorization", "Bearer "+config.Config.AccessToken)
-		extappdynamics.RestyClient.SetHeader("Content-Type", "application/json")
-
-		extevents.RestyClient = resty.New()
-		extevents.RestyClient.SetBaseURL(strings.TrimRight(config.Config.ApiBaseUrl, "/"))
-		extevents.RestyClient.SetHeader("Authorization", "Bearer "+config.Config.AccessToken)
-		extevents.RestyClient.SetHeader("Content-Type", "application/json")
-	} else {
-		tokenUrl := fmt.Sprintf("%s/controller/api/oauth/access_token", config.Config.ApiBaseUrl)
-		oauth2ClientCredentials := clientcredentials.Config{
-			ClientID:     fmt.Sprintf("%s@%s", config.Config.ApiClientName, config.Config.AccountName),
-			ClientSecret: config.Config.ApiClientSecret,
-			TokenURL:     tokenUrl,
-			AuthStyle:    oauth2.AuthStyleInParams,
-		}
-		tokenHttpClient := &http.Client{
-			Transport: &basicAuthTransport{
-				base:     http.DefaultTransport,
-				username: config.Config.ApiClientName,
-				password: config.Config.ApiClientSecret,
-				tokenURL: tokenUrl,
-			},
-		}
-		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, tokenHttpClient)
-		httpClient := oauth2ClientCredentials.Client(ctx)
+// initTransport creates the transport shared by the clients of the controllers and of the Analytics Events API.
+func initTransport() http.RoundTripper {
+	transport, err := appdclient.NewTransport(appdclient.TransportOptions{
+		ProxyUrl:           config.Config.ControllerProxyUrl,
+		NoProxy:            config.Config.ControllerNoProxy,
+		CaBundlePaths:      config.Config.ControllerCaBundlePaths,
+		ClientCertPath:     config.Config.ControllerClientCertPath,
+		ClientKeyPath:      config.Config.ControllerClientKeyPath,
+		InsecureSkipVerify: config.Config.ControllerInsecureSkipVerify,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up the connection to the AppDynamics controllers.")
+	}
+	return transport
+}
 
-		extappdynamics.RestyClient = resty.NewWithClient(httpClient)
-		extappdynamics.RestyClient.SetBaseURL(strings.TrimRight(config.Config.ApiBaseUrl, "/"))
-		extappdynamics.RestyClient.SetHeader("Content-Type", "application/json")
+func initAuditLog() *appdclient.AuditLog {
+	if config.Config.AuditLog == "" {
+		return nil
+	}
+	return appdclient.NewAuditLog(config.Config.AuditLog)
+}
 
-		extevents.RestyClient = resty.NewWithClient(httpClient)
-		extevents.RestyClient.SetBaseURL(strings.TrimRight(config.Config.ApiBaseUrl, "/"))
-		extevents.RestyClient.SetHeader("Content-Type", "application/json")
+// clientOptions returns the retry, rate limit and audit options applied to every AppDynamics client.
+func clientOptions(name string, auditLog *appdclient.AuditLog) []appdclient.Option {
+	return []appdclient.Option{
+		appdclient.WithName(name),
+		appdclient.WithRetryPolicy(appdclient.RetryPolicy{
+			MaxAttempts: config.Config.ControllerRetryMaxAttempts,
+			WaitTime:    config.Config.ControllerRetryWaitTime,
+			MaxWaitTime: config.Config.ControllerRetryMaxWaitTime,
+			Jitter:      config.Config.ControllerRetryJitter,
+		}),
+		appdclient.WithRateLimit(config.Config.ControllerRateLimit, config.Config.ControllerRateLimitBurst),
+		appdclient.WithAuditLog(auditLog),
 	}
 }
 
-type basicAuthTransport struct {
-	base     http.RoundTripper
-	username string
-	password string
-	tokenURL string
+// initControllers creates the clients of all configured controllers. It also returns the client of the default
+// controller, to which custom events are posted, and a function re-reading the secrets of the controllers.
+func initControllers(transport http.RoundTripper, auditLog *appdclient.AuditLog) (extappdynamics.Controllers, *appdclient.Client, func()) {
+	var controllers extappdynamics.Controllers
+	var defaultClient *appdclient.Client
+	credentials := make(map[string]*appdclient.Credentials)
+	for _, controller := range config.GetControllers() {
+		// The secret was already read successfully during the validation of the configuration.
+		secret, _ := controller.ReadSecret()
+		credentials[controller.Name] = appdclient.NewCredentials(controller.ApiClientName, controller.AccountName, secret)
+		client := appdclient.New(newControllerRestyClient(controller, credentials[controller.Name], transport),
+			clientOptions(controller.Name, auditLog)...)
+		if defaultClient == nil {
+			defaultClient = client
+		}
+		controllers = append(controllers, &extappdynamics.Controller{
+			Name:                    controller.Name,
+			ApplicationFilter:       controller.ApplicationFilter,
+			ApplicationNameIncludes: controller.ApplicationNameIncludes,
+			ApplicationNameExcludes: controller.ApplicationNameExcludes,
+			ApplicationAccountGuids: controller.ApplicationAccountGuids,
+			Client:                  client,
+			Credentials:             credentials[controller.Name],
+		})
+	}
+
+	reloadSecrets := func() {
+		for _, controller := range config.GetControllers() {
+			secret, err := controller.ReadSecret()
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to reload the secret of controller '%s'.", controller.Name)
+				continue
+			}
+			if credentials[controller.Name].SetSecret(secret) {
+				log.Info().Msgf("Reloaded the secret of controller '%s'.", controller.Name)
+			}
+		}
+	}
+	return controllers, defaultClient, reloadSecrets
 }
 
-func (t *basicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	// Add Basic Auth only to token requests
-	if req.URL.String() == t.tokenURL {
-		auth := base64.StdEncoding.EncodeToString([]byte(t.username + ":" + t.password))
-		req.Header.Set("Authorization", "Basic "+auth)
+// initAnalyticsClient creates the client of the Analytics Events API, or returns nil if it isn't configured.
+func initAnalyticsClient(transport http.RoundTripper, auditLog *appdclient.AuditLog) extevents.AnalyticsClient {
+	if config.Config.AnalyticsEventsApiUrl == "" {
+		return nil
 	}
+	client := resty.New()
+	client.SetTransport(transport)
+	client.SetBaseURL(strings.TrimRight(config.Config.AnalyticsEventsApiUrl, "/"))
+	client.SetHeader("X-Events-API-AccountName", config.Config.AnalyticsGlobalAccountName)
+	client.SetHeader("X-Events-API-Key", config.Config.AnalyticsApiKey)
+	return appdclient.New(client, clientOptions("analytics", auditLog)...)
+}
 
-	return t.base.RoundTrip(req)
+// newControllerRestyClient creates the client of a controller. The transport is used for the token requests as well as
+// for the API requests.
+func newControllerRestyClient(controller config.ControllerSpecification, credentials *appdclient.Credentials, transport http.RoundTripper) *resty.Client {
+	var client *resty.Client
+	if controller.UsesAccessToken() {
+		client = resty.New()
+		client.SetTransport(transport)
+		client.OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+			req.SetHeader("Authorization", "Bearer "+credentials.Secret())
+			return nil
+		})
+	} else {
+		client = resty.NewWithClient(appdclient.NewOAuthHttpClient(controller.ApiBaseUrl, credentials, transport))
+	}
+	client.SetBaseURL(strings.TrimRight(controller.ApiBaseUrl, "/"))
+	client.SetHeader("Content-Type", "application/json")
+	return client
 }
 
 type ExtensionListResponse struct {
@@ -136,7 +235,7 @@ func getExtensionList() ExtensionListResponse {
 		ActionList:    action_kit_sdk.GetActionList(),
 		DiscoveryList: discovery_kit_sdk.GetDiscoveryList(),
 	}
-	if config.Config.EventApplicationID != "" {
+	if extevents.IsEnabled() {
 		extList.EventListenerList = event_kit_api.EventListenerList{
 			EventListeners: []event_kit_api.EventListener{
 				{
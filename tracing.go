@@ -0,0 +1,65 @@
+/*
+ * Copyright 2025 steadybit GmbH. All rights reserved.
+ */
+
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/extension-appdynamics/config"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extsignals"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+)
+
+// initTracing exports the spans of the action calls and controller requests via OTLP/HTTP if an endpoint is
+// configured. Otherwise, the spans are dropped by the default no-op tracer provider.
+func initTracing() {
+	if config.Config.TracingOtlpEndpoint == "" {
+		return
+	}
+
+	ctx := context.Background()
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(config.Config.TracingOtlpEndpoint))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create the OTLP trace exporter.")
+	}
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(
+			semconv.ServiceName("steadybit-extension-appdynamics"),
+			semconv.ServiceVersion(extbuild.GetSemverVersionStringOrUnknown()),
+		),
+	)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to detect the tracing resource, using the defaults.")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.Config.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	extsignals.AddSignalHandler(extsignals.SignalHandler{
+		Handler: func(os.Signal) {
+			if err := provider.Shutdown(context.Background()); err != nil {
+				log.Warn().Err(err).Msg("Failed to flush the remaining spans.")
+			}
+		},
+		Order: extsignals.OrderStopCustom,
+		Name:  "tracing",
+	})
+	log.Info().Str("endpoint", config.Config.TracingOtlpEndpoint).Float64("sampleRatio", config.Config.TracingSampleRatio).Msg("Exporting traces via OTLP.")
+}